@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cmbrandenburg/ttr-pathgen/ttr"
+)
+
+// The output format, set by the -format flag, which may be given before the
+// command name or among the command's flags.
+var outputFormat = "text"
+
+func addFormatFlag(flags *flag.FlagSet) {
+	flags.StringVar(&outputFormat, "format", outputFormat, "output format: text, json, or csv")
+}
+
+// A command's result in every output format.
+type result struct {
+	text      func(w io.Writer)
+	json      interface{}
+	csvHeader []string
+	csvRows   [][]string
+}
+
+// Writes the result to standard output in the output format, exiting on error.
+func printResult(res result) {
+	var err error
+	switch outputFormat {
+	case "text":
+		res.text(os.Stdout)
+	case "json":
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(res.json)
+	case "csv":
+		w := csv.NewWriter(os.Stdout)
+		w.Write(res.csvHeader)
+		err = w.WriteAll(res.csvRows)
+	default:
+		ePrintf("invalid output format %q", outputFormat)
+		os.Exit(1)
+	}
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+}
+
+// JSON forms of the model, as documented in the README.
+
+type jsonDest struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Value int    `json:"value"`
+}
+
+type jsonRoute struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Length  int    `json:"length"`
+	Color   string `json:"color"`
+	Tunnel  bool   `json:"tunnel,omitempty"`
+	Ferries int    `json:"ferries,omitempty"`
+}
+
+type jsonPath struct {
+	Cities  []string         `json:"cities"`
+	Routes  []jsonRoute      `json:"routes"`
+	Hops    int              `json:"hops"`
+	Length  int              `json:"length"`
+	Hazards int              `json:"hazards"`
+	Cards   []ttr.ColorCount `json:"cards,omitempty"` // only from the path command
+}
+
+func newJSONDests(dests []*ttr.Dest) []jsonDest {
+	s := []jsonDest{}
+	for _, d := range dests {
+		s = append(s, jsonDest{From: d.City1.Name, To: d.City2.Name, Value: d.Value})
+	}
+	return s
+}
+
+func newJSONRoute(from, to *ttr.City, r *ttr.Route) jsonRoute {
+	return jsonRoute{From: from.Name, To: to.Name, Length: r.Dist, Color: r.Color, Tunnel: r.Tunnel, Ferries: r.Ferries}
+}
+
+func newJSONPath(p *ttr.Path) jsonPath {
+	jp := jsonPath{Hops: len(p.Routes), Length: p.Dist, Hazards: p.Hazards, Routes: []jsonRoute{}}
+	for i, c := range p.Cities {
+		jp.Cities = append(jp.Cities, c.Name)
+		if i > 0 {
+			jp.Routes = append(jp.Routes, newJSONRoute(p.Cities[i-1], c, p.Routes[i-1]))
+		}
+	}
+	return jp
+}
+
+// CSV forms of the model, as documented in the README. Lists within a field
+// are separated by semicolons.
+
+func destRows(dests []*ttr.Dest) (rows [][]string) {
+	for _, d := range dests {
+		rows = append(rows, []string{d.City1.Name, d.City2.Name, strconv.Itoa(d.Value)})
+	}
+	return
+}
+
+func routeFields(r *ttr.Route) []string {
+	return []string{strconv.Itoa(r.Dist), r.Color, strconv.FormatBool(r.Tunnel), strconv.Itoa(r.Ferries)}
+}
+
+// Returns the path's hops, length, hazards, cities, and routes, e.g., "2 red".
+func pathFields(p *ttr.Path) []string {
+	var cities, routes []string
+	for _, c := range p.Cities {
+		cities = append(cities, c.Name)
+	}
+	for _, r := range p.Routes {
+		routes = append(routes, r.String())
+	}
+	return []string{strconv.Itoa(len(p.Routes)), strconv.Itoa(p.Dist), strconv.Itoa(p.Hazards),
+		strings.Join(cities, ";"), strings.Join(routes, ";")}
+}
+
+// Returns the cards as text separated by sep, e.g., "6 orange, 4 wild".
+func cardsText(cards []ttr.ColorCount, sep string) string {
+	var s []string
+	for _, cc := range cards {
+		s = append(s, fmt.Sprintf("%d %s", cc.Count, cc.Color))
+	}
+	return strings.Join(s, sep)
+}
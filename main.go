@@ -1,9 +1,16 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cmbrandenburg/ttr-pathgen/ttr"
 )
 
 const (
@@ -21,70 +28,1072 @@ func ePrintln(a ...interface{}) {
 	fmt.Fprintln(os.Stderr, new_args...)
 }
 
+// Flags common to all commands that load a map.
+type mapFlags struct {
+	mapFile   string
+	destsFile string
+}
+
+// Registers the -map flag, plus its legacy alias -routes, the -format flag,
+// and, if withDests is true, the -dests flag.
+func addMapFlags(flags *flag.FlagSet, withDests bool) *mapFlags {
+	mf := new(mapFlags)
+	flags.StringVar(&mf.mapFile, "map", "usa", "bundled map name (see list-maps), or map file: a JSON map or a route file")
+	flags.StringVar(&mf.mapFile, "routes", "usa", "same as -map")
+	if withDests {
+		flags.StringVar(&mf.destsFile, "dests", "", "file to load destinations from (default: the map's destinations)")
+	}
+	addFormatFlag(flags)
+	return mf
+}
+
+// Loads the map, exiting on error, as do the other mapFlags methods.
+func (mf *mapFlags) mustLoadMap() *ttr.Map {
+	m, err := ttr.LoadMap(mf.mapFile)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	return m
+}
+
+func (mf *mapFlags) mustLoadUniv() (*ttr.Univ, *ttr.Map) {
+	m := mf.mustLoadMap()
+	u, err := ttr.NewUniv(m)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	return u, m
+}
+
+// Returns the name of the destination file to load, given the file named on
+// the command line, or the empty string to use the map's own destinations.
+// Route files have no destinations, so for them the default file is
+// destinations.dat.
+func destFileOrDefault(m *ttr.Map, filename string) string {
+	if filename == "" && m.NumDests() == 0 {
+		return "destinations.dat"
+	}
+	return filename
+}
+
+// Loads destinations from the given file or, if the file name is empty,
+// returns the map's own destinations.
+func (mf *mapFlags) mustLoadDests(u *ttr.Univ, m *ttr.Map, filename string) []*ttr.Dest {
+	var dests []*ttr.Dest
+	var err error
+	if filename = destFileOrDefault(m, filename); filename != "" {
+		dests, err = ttr.LoadDests(u, filename)
+	} else {
+		dests, err = m.Dests(u)
+	}
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	return dests
+}
+
+// Replaces the map's destinations with those from the given file, if any.
+func (mf *mapFlags) mustReplaceDests(m *ttr.Map, filename string) {
+	if filename = destFileOrDefault(m, filename); filename == "" {
+		return
+	}
+	if err := m.ReplaceDests(filename); err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+}
+
+// Replaces the map's regions with those from the given file, if any.
+func (mf *mapFlags) mustReplaceRegions(m *ttr.Map, filename string) {
+	if filename == "" {
+		return
+	}
+	if err := m.ReplaceRegions(filename); err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+}
+
 func makeDests() {
 
 	// TODO: check for and remove duplicate destinations
 
-	u := newUniv(mustLoadRouteEntriesFromFile("routes.dat"))
-	dests := makeDestsEqualLikely(u)
-	printDests(dests)
+	flags := flag.NewFlagSet("make-dests", flag.ExitOnError)
+	numDests := flags.Int("n", 30, "number of destinations to make")
+	seed := flags.Int64("seed", 0, "random number seed (default: based on current time)")
+	mf := addMapFlags(flags, false)
+	outFile := flags.String("out", "", "file to write destinations to, in destination-file format (default: standard output)")
+	mode := flags.String("mode", "equal", "how to choose city pairs: equal, weighted, or constrained")
+	valueSpec := flags.String("values", "", "weighted mode: value bucket weights, e.g., short=10,medium=12,long=8")
+	likeFile := flags.String("like", "", "weighted mode: learn value bucket weights from this destination file (default: the map's destinations)")
+	maxAttempts := flags.Int("max-attempts", 0, "equal mode: most city pairs to draw before giving up (default: 1000 per destination)")
+	var dc ttr.DeckConstraints
+	var forbidden stringList
+	flags.IntVar(&dc.MinValue, "min-value", 0, "constrained mode: smallest destination value")
+	flags.IntVar(&dc.MaxValue, "max-value", 0, "constrained mode: largest destination value (default: no limit)")
+	flags.IntVar(&dc.MinHops, "min-hops", 2, "constrained mode: fewest hops between a destination's cities")
+	flags.IntVar(&dc.MaxPerCity, "max-per-city", 0, "constrained mode: most destinations per city (default: the number of cities adjacent to the city)")
+	flags.Var((*stringList)(&dc.RequiredCities), "require", "constrained mode: city to include in at least one destination (repeatable)")
+	flags.Var(&forbidden, "forbid", "constrained mode: city pair not to use, e.g., \"Denver - Miami\" (repeatable)")
+	flags.IntVar(&dc.MinLong, "min-long", 0, "constrained mode: fewest destinations in the long value bucket")
+	flags.BoolVar(&dc.NoNestedPaths, "no-nested-paths", false, "constrained mode: don't let one destination's shortest path lie within another's")
+	scorerName := flags.String("score", "fewest-hops", "how to value destinations: "+strings.Join(ttr.ScorerNames, ", "))
+	officialFile := flags.String("official", "", "official destination file, used by the official scorer (default: the map's destinations)")
+	players := flags.Int("players", 4, "number of players, used by the bottleneck and redundancy scorers for double-route rules")
+	flags.Parse(os.Args[1:])
+
+	seedSet := false
+	flags.Visit(func(f *flag.Flag) {
+		if f.Name == "seed" {
+			seedSet = true
+		}
+	})
+	if !seedSet {
+		*seed = time.Now().UnixNano()
+	}
+	ePrintf("using seed %d", *seed)
+
+	u, m := mf.mustLoadUniv()
+	var official []*ttr.Dest
+	if *scorerName == "official" {
+		official = mf.mustLoadDests(u, m, *officialFile)
+	}
+	for _, pair := range forbidden {
+		index := strings.Index(pair, "-")
+		if index == -1 {
+			ePrintf("missing '-' in city pair %q", pair)
+			os.Exit(1)
+		}
+		dc.ForbiddenPairs = append(dc.ForbiddenPairs, [2]string{strings.TrimSpace(pair[:index]),
+			strings.TrimSpace(pair[index+1:])})
+	}
+	dc.MinHopsSet = true
+	opts := ttr.GenerateOptions{N: *numDests, Seed: *seed, Mode: *mode, Constraints: dc,
+		MaxAttempts: *maxAttempts}
+	var err error
+	if opts.Scorer, err = ttr.NewScorer(*scorerName, official, u, *players); err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	if *mode == "weighted" {
+		if *valueSpec != "" {
+			if opts.Buckets, err = ttr.ParseValueBuckets(*valueSpec); err != nil {
+				ePrintln(err)
+				os.Exit(1)
+			}
+		} else {
+			opts.Buckets = ttr.LearnValueBuckets(mf.mustLoadDests(u, m, *likeFile))
+		}
+	}
+
+	dests, err := ttr.GenerateDestinations(u, opts)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+
+	if *outFile == "" {
+		printResult(destsResult(dests))
+		return
+	}
+	file, err := os.Create(*outFile)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	err = ttr.WriteDests(file, dests)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+}
+
+// A flag that may be repeated, collecting each value.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ", ")
+}
+
+func (l *stringList) Set(s string) error {
+	*l = append(*l, s)
+	return nil
 }
 
-func printDests(dests []*dest) {
-	for _, d := range dests {
-		fmt.Printf("%q – %q : %d\n", d.city1.name, d.city2.name, d.value)
+func destsResult(dests []*ttr.Dest) result {
+	return result{
+		text: func(w io.Writer) {
+			for _, d := range dests {
+				fmt.Fprintf(w, "%q – %q : %d\n", d.City1.Name, d.City2.Name, d.Value)
+			}
+		},
+		json:      newJSONDests(dests),
+		csvHeader: []string{"from", "to", "value"},
+		csvRows:   destRows(dests),
 	}
 }
 
-func showDests() {
-	u := newUniv(mustLoadRouteEntriesFromFile("routes.dat"))
-	ents := mustLoadDestEntriesFromFile("destinations.dat")
-	dests, err := newDestsFromDestEntries(u, ents)
+func analyzeDestsCmd() {
+	flags := flag.NewFlagSet("analyze-dests", flag.ExitOnError)
+	mf := addMapFlags(flags, false)
+	regionsFile := flags.String("regions", "", "file to load regions from (default: the map's regions)")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s analyze-dests [flags] [destination-file...]\n", PROG_NAME)
+		flags.PrintDefaults()
+	}
+	flags.Parse(os.Args[1:])
+	destFiles := flags.Args()
+	if len(destFiles) == 0 {
+		destFiles = []string{""} // the map's destinations
+	}
+
+	u, m := mf.mustLoadUniv()
+	mf.mustReplaceRegions(m, *regionsFile)
+	regions, err := m.Regions(u)
 	if err != nil {
 		ePrintln(err)
 		os.Exit(1)
 	}
-	printDests(dests)
+	type fileStats struct {
+		File  string                `json:"file"`
+		Stats *ttr.DestStatsSummary `json:"stats"`
+	}
+	var allStats []*ttr.DestStats
+	var jsonStats []fileStats
+	var rows [][]string
+	for i, destFile := range destFiles {
+		st, err := ttr.AnalyzeDests(u, mf.mustLoadDests(u, m, destFile), regions)
+		if err != nil {
+			ePrintln(err)
+			os.Exit(1)
+		}
+		if destFile == "" {
+			destFiles[i] = mf.mapFile
+		}
+		allStats = append(allStats, st)
+		sum := st.Summary()
+		jsonStats = append(jsonStats, fileStats{destFiles[i], sum})
+		row := func(section, name string, n int) {
+			rows = append(rows, []string{destFiles[i], section, name, strconv.Itoa(n)})
+		}
+		row("destinations", "", sum.NumDests)
+		for _, vc := range sum.Values {
+			row("values", strconv.Itoa(vc.Value), vc.Count)
+		}
+		for _, sections := range []struct {
+			name   string
+			counts []ttr.NameCount
+		}{{"buckets", sum.Buckets}, {"cities", sum.Cities}, {"regions", sum.Regions}} {
+			for _, nc := range sections.counts {
+				row(sections.name, nc.Name, nc.Count)
+			}
+		}
+		row("overlap", "pairs", sum.Overlap.Pairs)
+		row("overlap", "overlapping pairs", sum.Overlap.OverlappingPairs)
+		row("overlap", "max shared links", sum.Overlap.MaxShared)
+	}
+
+	printResult(result{
+		text: func(w io.Writer) {
+			for i, st := range allStats {
+				if i > 0 {
+					fmt.Fprintln(w)
+				}
+				fmt.Fprintf(w, "%s: ", destFiles[i])
+				ttr.WriteDestStats(w, st)
+			}
+		},
+		json:      jsonStats,
+		csvHeader: []string{"file", "section", "name", "count"},
+		csvRows:   rows,
+	})
 }
 
-func showRoutes() {
-	u := newUniv(mustLoadRouteEntriesFromFile("routes.dat"))
-	cities := u.allCitiesAlphabetical()
-	for _, orig := range cities {
-		numRoutes := 0
-		numTgts := 0
-		for _, routes := range orig.routes {
-			numTgts++
-			numRoutes += len(routes)
+func printCards() {
+	flags := flag.NewFlagSet("print-cards", flag.ExitOnError)
+	mf := addMapFlags(flags, true)
+	pageName := flags.String("page", "a4", "page size: a4 or letter")
+	outPrefix := flags.String("out", "cards", "prefix of the SVG files to write, one per page")
+	miniMap := flags.Bool("minimap", false, "draw a mini-map on each card (the map must have coordinates)")
+	flags.Parse(os.Args[1:])
+
+	page, err := ttr.FindPageSize(*pageName)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	u, m := mf.mustLoadUniv()
+	dests := mf.mustLoadDests(u, m, mf.destsFile)
+	var miniMapUniv *ttr.Univ
+	if *miniMap {
+		if !u.Located() {
+			ePrintf("%s doesn't have coordinates for every city", mf.mapFile)
+			os.Exit(1)
+		}
+		miniMapUniv = u
+	}
+
+	layout := ttr.NewCardLayout(page)
+	var filenames []string
+	for i, pageDests := range layout.Paginate(dests) {
+		filename := fmt.Sprintf("%s-%d.svg", *outPrefix, i+1)
+		file, err := os.Create(filename)
+		if err != nil {
+			ePrintln(err)
+			os.Exit(1)
+		}
+		err = ttr.WriteCardPage(file, layout, pageDests, miniMapUniv)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			ePrintln(err)
+			os.Exit(1)
+		}
+		filenames = append(filenames, filename)
+	}
+
+	var rows [][]string
+	for _, filename := range filenames {
+		rows = append(rows, []string{filename})
+	}
+	printResult(result{
+		text: func(w io.Writer) {
+			for _, filename := range filenames {
+				fmt.Fprintln(w, filename)
+			}
+		},
+		json:      filenames,
+		csvHeader: []string{"file"},
+		csvRows:   rows,
+	})
+}
+
+func renderMap() {
+	flags := flag.NewFlagSet("render-map", flag.ExitOnError)
+	mf := addMapFlags(flags, false)
+	outFile := flags.String("out", "map.svg", "SVG file to write")
+	flags.Parse(os.Args[1:])
+
+	u, _ := mf.mustLoadUniv()
+	if !u.Located() {
+		ePrintf("%s doesn't have coordinates for every city", mf.mapFile)
+		os.Exit(1)
+	}
+
+	file, err := os.Create(*outFile)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	err = ttr.WriteMapSVG(file, u)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+}
+
+func convertMap() {
+	flags := flag.NewFlagSet("convert-map", flag.ExitOnError)
+	mf := addMapFlags(flags, true)
+	name := flags.String("name", "", "name of the map (default: the map's current name)")
+	regionsFile := flags.String("regions", "", "file to load regions from (default: the map's regions)")
+	outFile := flags.String("out", "", "JSON map file to write (default: standard output)")
+	flags.Parse(os.Args[1:])
+
+	m := mf.mustLoadMap()
+	mf.mustReplaceDests(m, mf.destsFile)
+	mf.mustReplaceRegions(m, *regionsFile)
+	if *name != "" {
+		m.Name = *name
+	}
+
+	if *outFile == "" {
+		if err := ttr.WriteJSONMap(os.Stdout, m); err != nil {
+			ePrintln(err)
+			os.Exit(1)
+		}
+		return
+	}
+	file, err := os.Create(*outFile)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	err = ttr.WriteJSONMap(file, m)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+}
+
+func validateMapCmd() {
+	flags := flag.NewFlagSet("validate-map", flag.ExitOnError)
+	mf := addMapFlags(flags, true)
+	flags.Parse(os.Args[1:])
+
+	m := mf.mustLoadMap()
+	mf.mustReplaceDests(m, mf.destsFile)
+
+	problems := m.Validate()
+	type jsonProblem struct {
+		File    string `json:"file"`
+		Line    int    `json:"line"`
+		Message string `json:"message"`
+	}
+	jsonProblems := []jsonProblem{}
+	var rows [][]string
+	for _, p := range problems {
+		jsonProblems = append(jsonProblems, jsonProblem{p.File, p.Line, p.Msg})
+		rows = append(rows, []string{p.File, strconv.Itoa(p.Line), p.Msg})
+	}
+	printResult(result{
+		text: func(w io.Writer) {
+			for _, p := range problems {
+				fmt.Fprintln(w, p)
+			}
+			if len(problems) == 0 {
+				fmt.Fprintf(w, "%s: no problems found\n", mf.mapFile)
+			}
+		},
+		json:      jsonProblems,
+		csvHeader: []string{"file", "line", "message"},
+		csvRows:   rows,
+	})
+	if len(problems) > 0 {
+		ePrintf("found %d problem(s)", len(problems))
+		os.Exit(1)
+	}
+}
+
+func listMaps() {
+	flags := flag.NewFlagSet("list-maps", flag.ExitOnError)
+	addFormatFlag(flags)
+	flags.Parse(os.Args[1:])
+
+	type mapInfo struct {
+		Name         string `json:"name"`
+		Title        string `json:"title"`
+		Cities       int    `json:"cities"`
+		Routes       int    `json:"routes"`
+		Destinations int    `json:"destinations"`
+	}
+	var infos []mapInfo
+	var rows [][]string
+	for _, name := range ttr.BundledMapNames() {
+		m, err := ttr.LoadBundledMap(name)
+		if err != nil {
+			ePrintln(err)
+			os.Exit(1)
+		}
+		u, err := ttr.NewUniv(m)
+		if err != nil {
+			ePrintln(err)
+			os.Exit(1)
 		}
-		fmt.Printf("%q (%d, %d)\n", orig.name, numTgts, numRoutes)
-		for tgt, routes := range orig.routes {
-			for _, r := range routes {
-				fmt.Printf("\t%q: %d %s\n", tgt.name, r.dist, r.color)
+		info := mapInfo{name, m.Name, len(u.Cities()), m.NumRoutes(), m.NumDests()}
+		infos = append(infos, info)
+		rows = append(rows, []string{info.Name, info.Title, strconv.Itoa(info.Cities), strconv.Itoa(info.Routes),
+			strconv.Itoa(info.Destinations)})
+	}
+	printResult(result{
+		text: func(w io.Writer) {
+			for _, info := range infos {
+				fmt.Fprintf(w, "%-12s %-20s %3d cities, %3d routes, %3d destinations\n", info.Name, info.Title,
+					info.Cities, info.Routes, info.Destinations)
 			}
+		},
+		json:      infos,
+		csvHeader: []string{"name", "title", "cities", "routes", "destinations"},
+		csvRows:   rows,
+	})
+}
+
+func routeUsage() {
+	flags := flag.NewFlagSet("route-usage", flag.ExitOnError)
+	mf := addMapFlags(flags, true)
+	pathKind := flags.String("paths", "shortest", "which path each destination takes: shortest or fewest-hops")
+	svgFile := flags.String("svg", "", "also write the map with a heat overlay to this SVG file (the map must have coordinates)")
+	flags.Parse(os.Args[1:])
+
+	if *pathKind != "shortest" && *pathKind != "fewest-hops" {
+		ePrintf("invalid path kind %q", *pathKind)
+		os.Exit(1)
+	}
+	u, m := mf.mustLoadUniv()
+	dests := mf.mustLoadDests(u, m, mf.destsFile)
+	usage, err := ttr.CountLinkUsage(u, dests, *pathKind == "fewest-hops")
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+
+	type jsonUsage struct {
+		Rank   int         `json:"rank"`
+		Count  int         `json:"count"`
+		From   string      `json:"from"`
+		To     string      `json:"to"`
+		Routes []jsonRoute `json:"routes"`
+	}
+	var jsonUsages []jsonUsage
+	var rows [][]string
+	for i, lu := range usage {
+		ju := jsonUsage{Rank: i + 1, Count: lu.Count, From: lu.City1.Name, To: lu.City2.Name}
+		var routes []string
+		for _, r := range lu.Routes {
+			ju.Routes = append(ju.Routes, newJSONRoute(lu.City1, lu.City2, r))
+			routes = append(routes, r.String())
 		}
+		jsonUsages = append(jsonUsages, ju)
+		rows = append(rows, []string{strconv.Itoa(i + 1), strconv.Itoa(lu.Count), lu.City1.Name, lu.City2.Name,
+			strings.Join(routes, ";")})
+	}
+	printResult(result{
+		text: func(w io.Writer) {
+			for i, lu := range usage {
+				var routes []string
+				for _, r := range lu.Routes {
+					routes = append(routes, r.String())
+				}
+				fmt.Fprintf(w, "%3d. %3d  %q – %q (%s)\n", i+1, lu.Count, lu.City1.Name, lu.City2.Name,
+					strings.Join(routes, ", "))
+			}
+		},
+		json:      jsonUsages,
+		csvHeader: []string{"rank", "count", "from", "to", "routes"},
+		csvRows:   rows,
+	})
+
+	if *svgFile == "" {
+		return
+	}
+	if !u.Located() {
+		ePrintf("%s doesn't have coordinates for every city", mf.mapFile)
+		os.Exit(1)
+	}
+	file, err := os.Create(*svgFile)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	err = ttr.WriteUsageSVG(file, u, usage)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
 	}
 }
 
+func simulate() {
+	flags := flag.NewFlagSet("simulate", flag.ExitOnError)
+	mf := addMapFlags(flags, true)
+	var opts ttr.SimulateOptions
+	flags.IntVar(&opts.Games, "games", 1000, "number of games to play")
+	flags.IntVar(&opts.Players, "players", 4, "number of players")
+	flags.Int64Var(&opts.Seed, "seed", 1, "random number seed")
+	flags.IntVar(&opts.Tickets, "tickets", 3, "destinations dealt to each player")
+	flags.IntVar(&opts.Trains, "trains", 45, "trains each player starts with")
+	flags.Parse(os.Args[1:])
+
+	u, m := mf.mustLoadUniv()
+	dests := mf.mustLoadDests(u, m, mf.destsFile)
+	sim, err := ttr.Simulate(u, dests, opts)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+
+	type jsonTicket struct {
+		jsonDest
+		Dealt          int     `json:"dealt"`
+		Completed      int     `json:"completed"`
+		CompletionRate float64 `json:"completionRate"`
+		AvgPoints      float64 `json:"avgPoints"`
+	}
+	type jsonSimulation struct {
+		Games    int          `json:"games"`
+		Players  int          `json:"players"`
+		AvgScore float64      `json:"avgScore"`
+		Dests    []jsonTicket `json:"destinations"`
+	}
+	js := jsonSimulation{Games: sim.Games, Players: opts.Players, AvgScore: sim.AvgScore, Dests: []jsonTicket{}}
+	var rows [][]string
+	for _, ts := range sim.Tickets {
+		d := ts.Dest
+		js.Dests = append(js.Dests, jsonTicket{jsonDest{d.City1.Name, d.City2.Name, d.Value}, ts.Dealt, ts.Completed,
+			ts.CompletionRate(), ts.AvgPoints()})
+		rows = append(rows, []string{d.City1.Name, d.City2.Name, strconv.Itoa(d.Value), strconv.Itoa(ts.Dealt),
+			strconv.Itoa(ts.Completed), strconv.FormatFloat(ts.CompletionRate(), 'f', 3, 64),
+			strconv.FormatFloat(ts.AvgPoints(), 'f', 2, 64)})
+	}
+
+	printResult(result{
+		text: func(w io.Writer) {
+			for _, ts := range sim.Tickets {
+				d := ts.Dest
+				fmt.Fprintf(w, "%q – %q : %d (dealt %d, completed %.0f%%, average %.1f points)\n", d.City1.Name,
+					d.City2.Name, d.Value, ts.Dealt, 100*ts.CompletionRate(), ts.AvgPoints())
+			}
+			fmt.Fprintf(w, "average score: %.1f over %d games\n", sim.AvgScore, sim.Games)
+		},
+		json:      js,
+		csvHeader: []string{"from", "to", "value", "dealt", "completed", "completionRate", "avgPoints"},
+		csvRows:   rows,
+	})
+}
+
+func showDests() {
+	flags := flag.NewFlagSet("show-dests", flag.ExitOnError)
+	mf := addMapFlags(flags, true)
+	players := flags.Int("players", 4, "number of players, for double-route rules")
+	flags.Parse(os.Args[1:])
+
+	u, m := mf.mustLoadUniv()
+	type jsonDestInfo struct {
+		jsonDest
+		DisjointPaths int              `json:"disjointPaths"`
+		MinCut        int              `json:"minCut"`
+		Bottlenecks   [][2]string      `json:"bottlenecks"`
+		Cards         []ttr.ColorCount `json:"cards"`
+		Colors        int              `json:"colors"`
+	}
+	infos := []jsonDestInfo{}
+	var rows [][]string
+	for _, d := range mf.mustLoadDests(u, m, mf.destsFile) {
+		red := u.Redundancy(d.City1, d.City2, *players)
+		p, err := u.FewestColorsPath(d.City1.Name, d.City2.Name)
+		if err != nil {
+			ePrintln(err)
+			os.Exit(1)
+		}
+		info := jsonDestInfo{
+			jsonDest:      jsonDest{d.City1.Name, d.City2.Name, d.Value},
+			DisjointPaths: red.DisjointPaths,
+			MinCut:        red.MinCut,
+			Bottlenecks:   [][2]string{},
+			Cards:         p.CardsNeeded(),
+			Colors:        p.NumCardColors(),
+		}
+		var bottlenecks []string
+		for _, b := range red.Bottlenecks {
+			info.Bottlenecks = append(info.Bottlenecks, [2]string{b[0].Name, b[1].Name})
+			bottlenecks = append(bottlenecks, b[0].Name+" - "+b[1].Name)
+		}
+		infos = append(infos, info)
+		rows = append(rows, []string{d.City1.Name, d.City2.Name, strconv.Itoa(d.Value), strconv.Itoa(red.DisjointPaths),
+			strconv.Itoa(red.MinCut), strings.Join(bottlenecks, ";"), cardsText(info.Cards, ";"), strconv.Itoa(info.Colors)})
+	}
+
+	printResult(result{
+		text: func(w io.Writer) {
+			for _, info := range infos {
+				desc := fmt.Sprintf("%d disjoint paths, min cut %d", info.DisjointPaths, info.MinCut)
+				for i, b := range info.Bottlenecks {
+					if i == 0 {
+						desc += ", bottlenecks"
+					}
+					desc += fmt.Sprintf(" %q–%q", b[0], b[1])
+				}
+				desc += fmt.Sprintf(", cards %s", cardsText(info.Cards, ", "))
+				fmt.Fprintf(w, "%q – %q : %d (%s)\n", info.From, info.To, info.Value, desc)
+			}
+		},
+		json:      infos,
+		csvHeader: []string{"from", "to", "value", "disjointPaths", "minCut", "bottlenecks", "cards", "colors"},
+		csvRows:   rows,
+	})
+}
+
+func showRoutes() {
+	flags := flag.NewFlagSet("show-routes", flag.ExitOnError)
+	mf := addMapFlags(flags, false)
+	flags.Parse(os.Args[1:])
+
+	u, _ := mf.mustLoadUniv()
+	var routes []jsonRoute
+	var rows [][]string
+	for _, orig := range u.Cities() {
+		for _, tgt := range orig.Neighbors() {
+			for _, r := range orig.RoutesTo(tgt) {
+				routes = append(routes, newJSONRoute(orig, tgt, r))
+				rows = append(rows, append([]string{orig.Name, tgt.Name}, routeFields(r)...))
+			}
+		}
+	}
+
+	printResult(result{
+		text: func(w io.Writer) {
+			for _, orig := range u.Cities() {
+				tgts := orig.Neighbors()
+				numRoutes := 0
+				for _, tgt := range tgts {
+					numRoutes += len(orig.RoutesTo(tgt))
+				}
+				fmt.Fprintf(w, "%q (%d, %d)\n", orig.Name, len(tgts), numRoutes)
+				for _, tgt := range tgts {
+					for _, r := range orig.RoutesTo(tgt) {
+						fmt.Fprintf(w, "\t%q: %s\n", tgt.Name, r)
+					}
+				}
+			}
+		},
+		json:      routes,
+		csvHeader: []string{"from", "to", "length", "color", "tunnel", "ferries"},
+		csvRows:   rows,
+	})
+}
+
 func showShortestPaths() {
-	u := newUniv(mustLoadRouteEntriesFromFile("routes.dat"))
-	cities := u.allCitiesAlphabetical()
+	flags := flag.NewFlagSet("show-shortest-paths", flag.ExitOnError)
+	mf := addMapFlags(flags, false)
+	flags.Parse(os.Args[1:])
+
+	u, _ := mf.mustLoadUniv()
+	type pathPair struct {
+		From       string   `json:"from"`
+		To         string   `json:"to"`
+		FewestHops jsonPath `json:"fewestHops"`
+		Shortest   jsonPath `json:"shortest"`
+	}
+	var pairs []pathPair
+	var rows [][]string
+	var lines []string
+	cities := u.Cities()
 	for i, orig := range cities {
 		for j, tgt := range cities {
 			if i != j {
-				pFewest := orig.fewestHops[tgt]
-				pShortest := orig.shortestDist[tgt]
+				pFewest, err := u.FewestHopsPath(orig.Name, tgt.Name)
+				if err != nil {
+					ePrintln(err)
+					os.Exit(1)
+				}
+				pShortest, err := u.ShortestPath(orig.Name, tgt.Name)
+				if err != nil {
+					ePrintln(err)
+					os.Exit(1)
+				}
 				var desc string
-				if pFewest.equals(pShortest) {
-					desc = fmt.Sprintf("%d hops, %d length", len(pFewest.routes), pFewest.dist)
+				if pFewest.Equals(pShortest) {
+					desc = fmt.Sprintf("%d hops, %d length", len(pFewest.Routes), pFewest.Dist)
 				} else {
-					desc = fmt.Sprintf("%d hops, %d length OR %d hops, %d length", len(pFewest.routes), pFewest.dist, len(pShortest.routes),
-						pShortest.dist)
+					desc = fmt.Sprintf("%d hops, %d length OR %d hops, %d length", len(pFewest.Routes), pFewest.Dist, len(pShortest.Routes),
+						pShortest.Dist)
+				}
+				lines = append(lines, fmt.Sprintf("%q – %q: %s", orig.Name, tgt.Name, desc))
+				pairs = append(pairs, pathPair{orig.Name, tgt.Name, newJSONPath(pFewest), newJSONPath(pShortest)})
+				for _, p := range []struct {
+					kind string
+					path *ttr.Path
+				}{{"fewest-hops", pFewest}, {"shortest", pShortest}} {
+					rows = append(rows, append([]string{orig.Name, tgt.Name, p.kind}, pathFields(p.path)...))
 				}
-				fmt.Printf("%q – %q: %s\n", orig.name, tgt.name, desc)
 			}
 		}
 	}
+
+	printResult(result{
+		text: func(w io.Writer) {
+			for _, line := range lines {
+				fmt.Fprintln(w, line)
+			}
+		},
+		json:      pairs,
+		csvHeader: []string{"from", "to", "kind", "hops", "length", "hazards", "cities", "routes"},
+		csvRows:   rows,
+	})
+}
+
+func showPaths() {
+	flags := flag.NewFlagSet("show-paths", flag.ExitOnError)
+	mf := addMapFlags(flags, false)
+	k := flags.Int("k", 5, "number of paths to show")
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s show-paths [flags] <city> <city>\n", PROG_NAME)
+		flags.PrintDefaults()
+	}
+	args := parseInterspersed(flags, os.Args[1:])
+	if len(args) != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	if *k < 1 {
+		ePrintf("invalid number of paths %d", *k)
+		os.Exit(1)
+	}
+
+	u, _ := mf.mustLoadUniv()
+	paths, err := u.KShortestPaths(args[0], args[1], *k)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	var jsonPaths []jsonPath
+	var rows [][]string
+	for i, p := range paths {
+		jsonPaths = append(jsonPaths, newJSONPath(p))
+		rows = append(rows, append([]string{strconv.Itoa(i + 1)}, pathFields(p)...))
+	}
+	printResult(result{
+		text: func(w io.Writer) {
+			for i, p := range paths {
+				fmt.Fprintf(w, "%d: %d hops, %d length: %s\n", i+1, len(p.Routes), p.Dist, p)
+			}
+		},
+		json:      jsonPaths,
+		csvHeader: []string{"rank", "hops", "length", "hazards", "cities", "routes"},
+		csvRows:   rows,
+	})
+}
+
+func pathCmd() {
+	flags := flag.NewFlagSet("path", flag.ExitOnError)
+	mf := addMapFlags(flags, false)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s path [flags] <from-city> <to-city>\n", PROG_NAME)
+		flags.PrintDefaults()
+	}
+	args := parseInterspersed(flags, os.Args[1:])
+	if len(args) != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	u, _ := mf.mustLoadUniv()
+	var cities [2]*ttr.City
+	for i, name := range args {
+		c, err := u.FindCity(name)
+		if err != nil {
+			ePrintln(err)
+			os.Exit(1)
+		}
+		cities[i] = c
+	}
+	from, to := cities[0], cities[1]
+	// Where a path may take either route of a double route, take the one that
+	// needs the fewest colors of cards, so that the cards shown are all needed.
+	pFewest, err := u.FewestHopsPath(from.Name, to.Name)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	pFewest = pFewest.WithFewestColors()
+	pShortest, err := u.FewestColorsPath(from.Name, to.Name)
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+
+	type pathPair struct {
+		From       string   `json:"from"`
+		To         string   `json:"to"`
+		FewestHops jsonPath `json:"fewestHops"`
+		Shortest   jsonPath `json:"shortest"`
+	}
+	pair := pathPair{From: from.Name, To: to.Name, FewestHops: newJSONPath(pFewest), Shortest: newJSONPath(pShortest)}
+	pair.FewestHops.Cards = pFewest.CardsNeeded()
+	pair.Shortest.Cards = pShortest.CardsNeeded()
+	var rows [][]string
+	for _, p := range []struct {
+		kind string
+		path jsonPath
+	}{{"fewest-hops", pair.FewestHops}, {"shortest", pair.Shortest}} {
+		for i, r := range p.path.Routes {
+			rows = append(rows, []string{p.kind, strconv.Itoa(i + 1), r.From, r.To, strconv.Itoa(r.Length), r.Color,
+				strconv.FormatBool(r.Tunnel), strconv.Itoa(r.Ferries)})
+		}
+	}
+
+	printResult(result{
+		text: func(w io.Writer) {
+			writePath := func(title string, p *ttr.Path) {
+				fmt.Fprintf(w, "%s: %d hops, %d length\n", title, len(p.Routes), p.Dist)
+				for i, r := range p.Routes {
+					fmt.Fprintf(w, "\t%q – %q: %s\n", p.Cities[i].Name, p.Cities[i+1].Name, r)
+				}
+				fmt.Fprintf(w, "\tcards: %s\n", cardsText(p.CardsNeeded(), ", "))
+			}
+			writePath("Fewest hops", pFewest)
+			if pFewest.Equals(pShortest) {
+				fmt.Fprintln(w, "Shortest: same as fewest hops")
+			} else {
+				writePath("Shortest", pShortest)
+			}
+		},
+		json:      pair,
+		csvHeader: []string{"kind", "hop", "from", "to", "length", "color", "tunnel", "ferries"},
+		csvRows:   rows,
+	})
+}
+
+func longestRoute() {
+	flags := flag.NewFlagSet("longest-route", flag.ExitOnError)
+	mf := addMapFlags(flags, false)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s longest-route [flags] <claims-file>\n", PROG_NAME)
+		flags.PrintDefaults()
+	}
+	args := parseInterspersed(flags, os.Args[1:])
+	if len(args) != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	u, _ := mf.mustLoadUniv()
+	claims, err := ttr.LoadClaims(u, args[0])
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	p := ttr.LongestTrail(claims)
+	if p == nil {
+		ePrintf("%s: no claimed routes", args[0])
+		os.Exit(1)
+	}
+	var rows [][]string
+	for i, r := range p.Routes {
+		rows = append(rows, append([]string{strconv.Itoa(i + 1), p.Cities[i].Name, p.Cities[i+1].Name},
+			routeFields(r)...))
+	}
+
+	printResult(result{
+		text: func(w io.Writer) {
+			fmt.Fprintf(w, "Longest route: %d hops, %d length\n", len(p.Routes), p.Dist)
+			for i, r := range p.Routes {
+				fmt.Fprintf(w, "\t%q – %q: %s\n", p.Cities[i].Name, p.Cities[i+1].Name, r)
+			}
+		},
+		json:      newJSONPath(p),
+		csvHeader: []string{"hop", "from", "to", "length", "color", "tunnel", "ferries"},
+		csvRows:   rows,
+	})
+}
+
+func scoreCmd() {
+	flags := flag.NewFlagSet("score", flag.ExitOnError)
+	mf := addMapFlags(flags, false)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %s score [flags] <game-file>\n", PROG_NAME)
+		flags.PrintDefaults()
+	}
+	args := parseInterspersed(flags, os.Args[1:])
+	if len(args) != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	u, _ := mf.mustLoadUniv()
+	g, err := ttr.LoadGameState(u, args[0])
+	if err != nil {
+		ePrintln(err)
+		os.Exit(1)
+	}
+	scores := ttr.ScoreGame(g)
+
+	type jsonTicket struct {
+		jsonDest
+		Completed bool `json:"completed"`
+	}
+	type jsonScore struct {
+		Name              string                 `json:"name"`
+		Total             int                    `json:"total"`
+		RoutePoints       int                    `json:"routePoints"`
+		Routes            []ttr.RouteLengthCount `json:"routes"`
+		TicketPoints      int                    `json:"ticketPoints"`
+		Tickets           []jsonTicket           `json:"tickets"`
+		LongestRoute      int                    `json:"longestRoute"`
+		LongestRouteBonus int                    `json:"longestRouteBonus"`
+		StationsUsed      int                    `json:"stationsUsed"`
+		StationPoints     int                    `json:"stationPoints"`
+		Borrowed          []jsonRoute            `json:"borrowed"`
+	}
+	jsonScores := []jsonScore{}
+	var rows [][]string
+	for _, sc := range scores {
+		js := jsonScore{Name: sc.Name, Total: sc.Total, RoutePoints: sc.RoutePoints, Routes: sc.Routes,
+			TicketPoints: sc.TicketPoints, Tickets: []jsonTicket{}, LongestRoute: sc.LongestRoute,
+			LongestRouteBonus: sc.LongestRouteBonus, StationsUsed: sc.StationsUsed, StationPoints: sc.StationPoints,
+			Borrowed: []jsonRoute{}}
+		if js.Routes == nil {
+			js.Routes = []ttr.RouteLengthCount{}
+		}
+		completed := 0
+		for _, tr := range sc.Tickets {
+			d := tr.Dest
+			js.Tickets = append(js.Tickets, jsonTicket{jsonDest{d.City1.Name, d.City2.Name, d.Value}, tr.Completed})
+			if tr.Completed {
+				completed++
+			}
+		}
+		for _, cl := range sc.Borrowed {
+			js.Borrowed = append(js.Borrowed, newJSONRoute(cl.City1, cl.City2, cl.Route))
+		}
+		jsonScores = append(jsonScores, js)
+		rows = append(rows, []string{sc.Name, strconv.Itoa(sc.Total), strconv.Itoa(sc.RoutePoints),
+			strconv.Itoa(sc.TicketPoints), strconv.Itoa(completed), strconv.Itoa(len(sc.Tickets) - completed),
+			strconv.Itoa(sc.LongestRoute), strconv.Itoa(sc.LongestRouteBonus), strconv.Itoa(sc.StationsUsed),
+			strconv.Itoa(sc.StationPoints)})
+	}
+
+	printResult(result{
+		text: func(w io.Writer) {
+			for _, sc := range scores {
+				fmt.Fprintf(w, "%q: %d points\n", sc.Name, sc.Total)
+				fmt.Fprintf(w, "\troutes: %d points\n", sc.RoutePoints)
+				for _, rlc := range sc.Routes {
+					fmt.Fprintf(w, "\t\tlength %d: %d × %d = %d\n", rlc.Length, rlc.Count, ttr.RoutePoints(rlc.Length),
+						rlc.Points)
+				}
+				fmt.Fprintf(w, "\tdestinations: %d points\n", sc.TicketPoints)
+				for _, tr := range sc.Tickets {
+					outcome := "failed"
+					if tr.Completed {
+						outcome = "completed"
+					}
+					fmt.Fprintf(w, "\t\t%q – %q : %d %s\n", tr.Dest.City1.Name, tr.Dest.City2.Name, tr.Dest.Value,
+						outcome)
+				}
+				fmt.Fprintf(w, "\tlongest route: %d length, %d points\n", sc.LongestRoute, sc.LongestRouteBonus)
+				if g.StationsPerPlayer > 0 {
+					fmt.Fprintf(w, "\tstations: %d used, %d points\n", sc.StationsUsed, sc.StationPoints)
+				}
+				for _, cl := range sc.Borrowed {
+					fmt.Fprintf(w, "\t\tborrowed %q – %q: %s\n", cl.City1.Name, cl.City2.Name, cl.Route)
+				}
+			}
+		},
+		json: jsonScores,
+		csvHeader: []string{"name", "total", "routePoints", "ticketPoints", "completed", "failed", "longestRoute",
+			"longestRouteBonus", "stationsUsed", "stationPoints"},
+		csvRows: rows,
+	})
+}
+
+// Parses flags that may come before, after, or between the positional
+// arguments, e.g., "A B -k 5", and returns the positional arguments.
+func parseInterspersed(flags *flag.FlagSet, args []string) (positional []string) {
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
 }
 
 func usage() {
@@ -101,33 +1110,51 @@ func usage() {
 
 func main() {
 	allCmds := map[string]func(){
+		"analyze-dests":       analyzeDestsCmd,
+		"convert-map":         convertMap,
+		"list-maps":           listMaps,
+		"longest-route":       longestRoute,
 		"make-dests":          makeDests,
+		"path":                pathCmd,
+		"print-cards":         printCards,
+		"render-map":          renderMap,
+		"route-usage":         routeUsage,
+		"score":               scoreCmd,
 		"show-dests":          showDests,
+		"show-paths":          showPaths,
 		"show-routes":         showRoutes,
 		"show-shortest-paths": showShortestPaths,
+		"simulate":            simulate,
+		"validate-map":        validateMapCmd,
 	}
-	if len(os.Args) <= 1 {
+	global := flag.NewFlagSet(PROG_NAME, flag.ExitOnError)
+	addFormatFlag(global)
+	global.Usage = func() {
 		var sortedCmds []string
 		for cmd := range allCmds {
 			sortedCmds = append(sortedCmds, cmd)
 		}
 		sort.Strings(sortedCmds)
-		fmt.Println("usage:", PROG_NAME, "<command> <args>")
+		fmt.Println("usage:", PROG_NAME, "[-format text|json|csv] <command> <args>")
 		fmt.Println()
 		fmt.Println("Possible commands are:")
 		for _, cmd := range sortedCmds {
 			fmt.Printf("  %s\n", cmd)
 		}
 		fmt.Println()
+	}
+	global.Parse(os.Args[1:])
+	if global.NArg() == 0 {
+		global.Usage()
 		os.Exit(1)
 	}
-	cmd := os.Args[1]
-	copy(os.Args[1:], os.Args[2:])
-	os.Args = os.Args[:len(os.Args)-1]
+	cmd := global.Arg(0)
+	os.Args = append(os.Args[:1], global.Args()[1:]...)
 	f := allCmds[cmd]
 	if f != nil {
 		f()
 		return
 	}
 	ePrintf("invalid command %q", cmd)
+	os.Exit(1)
 }
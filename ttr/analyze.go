@@ -0,0 +1,279 @@
+package ttr
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// A region is a named group of cities, e.g., "Northeast", used for judging how
+// evenly a set of destinations covers the map.
+type Region struct {
+	Name   string
+	cities map[*City]bool
+}
+
+func newRegionsFromRegionEntries(u *Univ, ents []regionEnt) (regions []*Region, err error) {
+	for _, ent := range ents {
+		rgn := &Region{
+			Name:   ent.name,
+			cities: make(map[*City]bool),
+		}
+		for _, name := range ent.cityNames {
+			c := u.cityByName[name]
+			if c == nil {
+				return nil, fmt.Errorf("error creating region %q: city %q doesn't exist", ent.name, name)
+			}
+			rgn.cities[c] = true
+		}
+		regions = append(regions, rgn)
+	}
+	return
+}
+
+// Statistics about a set of destinations, used for judging whether the set is
+// balanced.
+type DestStats struct {
+	numDests     int
+	valueCounts  map[int]int
+	buckets      []ValueBucket
+	bucketCounts []int
+	cityCounts   map[*City]int
+	regions      []*Region
+	regionCounts []int
+
+	// Overlap is measured by the number of links that two destinations' shortest
+	// paths have in common.
+	numPairs       int
+	numOverlapping int
+	totalShared    int
+	maxShared      int
+	maxSharedDests [2]*Dest
+}
+
+// Analyzes a set of destinations. It's an error if a destination's cities
+// aren't connected.
+func AnalyzeDests(u *Univ, dests []*Dest, regions []*Region) (*DestStats, error) {
+	st := &DestStats{
+		numDests:     len(dests),
+		valueCounts:  make(map[int]int),
+		buckets:      DefaultValueBuckets(),
+		cityCounts:   make(map[*City]int),
+		regions:      regions,
+		regionCounts: make([]int, len(regions)),
+	}
+	st.bucketCounts = make([]int, len(st.buckets))
+
+	for _, d := range dests {
+		st.valueCounts[d.Value]++
+		if i := findValueBucket(st.buckets, d.Value); i != -1 {
+			st.bucketCounts[i]++
+		}
+		for i, rgn := range regions {
+			if rgn.cities[d.City1] || rgn.cities[d.City2] {
+				st.regionCounts[i]++
+			}
+		}
+	}
+
+	for _, c := range u.Cities() {
+		st.cityCounts[c] = countCityInDests(dests, c)
+	}
+
+	var allLinks []map[link]bool
+	for _, d := range dests {
+		p, err := destPath(d, false)
+		if err != nil {
+			return nil, err
+		}
+		allLinks = append(allLinks, p.links())
+	}
+	for i := range dests {
+		for j := i + 1; j < len(dests); j++ {
+			shared := 0
+			for l := range allLinks[i] {
+				if allLinks[j][l] {
+					shared++
+				}
+			}
+			st.numPairs++
+			st.totalShared += shared
+			if shared > 0 {
+				st.numOverlapping++
+			}
+			if shared > st.maxShared {
+				st.maxShared = shared
+				st.maxSharedDests = [2]*Dest{dests[i], dests[j]}
+			}
+		}
+	}
+
+	return st, nil
+}
+
+// A summary of destination statistics, with every list in the same order as
+// WriteDestStats writes it. The JSON field names are part of the command-line
+// tool's documented output.
+type DestStatsSummary struct {
+	NumDests int          `json:"destinations"`
+	Values   []ValueCount `json:"values"`
+	Buckets  []NameCount  `json:"buckets"` // names include value ranges, e.g., "short (1–9)"
+	Cities   []NameCount  `json:"cities"`
+	Regions  []NameCount  `json:"regions"`
+	Overlap  struct {
+		Pairs            int      `json:"pairs"`
+		OverlappingPairs int      `json:"overlappingPairs"`
+		MaxShared        int      `json:"maxSharedLinks"`
+		MaxSharedDests   []string `json:"maxSharedDestinations"` // e.g., "Boston – Miami"
+	} `json:"overlap"`
+}
+
+// A destination value with a count.
+type ValueCount struct {
+	Value int `json:"value"`
+	Count int `json:"count"`
+}
+
+// A name, e.g., of a city, with a count.
+type NameCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Returns the statistics in exported form, e.g., for encoding as JSON.
+func (st *DestStats) Summary() *DestStatsSummary {
+	sum := &DestStatsSummary{NumDests: st.numDests}
+	var values []int
+	for v := range st.valueCounts {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+	for _, v := range values {
+		sum.Values = append(sum.Values, ValueCount{v, st.valueCounts[v]})
+	}
+	for i, b := range st.buckets {
+		sum.Buckets = append(sum.Buckets, NameCount{fmt.Sprintf("%s (%s)", b.Name, b.rangeString()), st.bucketCounts[i]})
+	}
+	for _, c := range st.citiesByCount() {
+		sum.Cities = append(sum.Cities, NameCount{c.Name, st.cityCounts[c]})
+	}
+	for i, rgn := range st.regions {
+		sum.Regions = append(sum.Regions, NameCount{rgn.Name, st.regionCounts[i]})
+	}
+	sum.Overlap.Pairs = st.numPairs
+	sum.Overlap.OverlappingPairs = st.numOverlapping
+	sum.Overlap.MaxShared = st.maxShared
+	if st.maxShared > 0 {
+		for _, d := range st.maxSharedDests {
+			sum.Overlap.MaxSharedDests = append(sum.Overlap.MaxSharedDests, d.City1.Name+" – "+d.City2.Name)
+		}
+	}
+	return sum
+}
+
+// Returns the cities, most frequent first and then alphabetically.
+func (st *DestStats) citiesByCount() (cities []*City) {
+	for c := range st.cityCounts {
+		cities = append(cities, c)
+	}
+	sort.Slice(cities, func(i, j int) bool {
+		ni, nj := st.cityCounts[cities[i]], st.cityCounts[cities[j]]
+		return ni > nj || (ni == nj && cities[i].Name < cities[j].Name)
+	})
+	return
+}
+
+func WriteDestStats(w io.Writer, st *DestStats) {
+
+	fmt.Fprintf(w, "%d destinations\n", st.numDests)
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Values:")
+	var values []int
+	for v := range st.valueCounts {
+		values = append(values, v)
+	}
+	sort.Ints(values)
+	for _, v := range values {
+		n := st.valueCounts[v]
+		fmt.Fprintf(w, "\t%3d: %2d %s\n", v, n, strings.Repeat("#", n))
+	}
+	for i, b := range st.buckets {
+		fmt.Fprintf(w, "\t%s (%s): %d\n", b.Name, b.rangeString(), st.bucketCounts[i])
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Cities:")
+	for _, c := range st.citiesByCount() {
+		fmt.Fprintf(w, "\t%q: %d\n", c.Name, st.cityCounts[c])
+	}
+
+	if len(st.regions) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Regions:")
+		for i, rgn := range st.regions {
+			fmt.Fprintf(w, "\t%q: %d\n", rgn.Name, st.regionCounts[i])
+		}
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Shortest-path overlap:")
+	fmt.Fprintf(w, "\t%d of %d destination pairs share at least one link\n", st.numOverlapping, st.numPairs)
+	if st.numPairs > 0 {
+		fmt.Fprintf(w, "\t%.2f shared links per destination pair\n", float64(st.totalShared)/float64(st.numPairs))
+	}
+	if st.maxShared > 0 {
+		d1, d2 := st.maxSharedDests[0], st.maxSharedDests[1]
+		fmt.Fprintf(w, "\tmost overlap: %q – %q and %q – %q share %d links\n", d1.City1.Name, d1.City2.Name, d2.City1.Name,
+			d2.City2.Name, st.maxShared)
+	}
+}
+
+// The number of destinations whose paths cross a link.
+type LinkUsage struct {
+	City1  *City // in alphabetical order
+	City2  *City
+	Routes []*Route
+	Count  int
+}
+
+// Counts how many destinations' paths cross each link, using each
+// destination's fewest-hops path if fewestHops is true and otherwise its
+// shortest path. Returns every link, most used first and then alphabetically.
+// It's an error if a destination's cities aren't connected.
+func CountLinkUsage(u *Univ, dests []*Dest, fewestHops bool) (usage []LinkUsage, err error) {
+	counts := make(map[link]int)
+	for _, d := range dests {
+		p, err := destPath(d, fewestHops)
+		if err != nil {
+			return nil, err
+		}
+		for l := range p.links() {
+			counts[l]++
+		}
+	}
+	for _, l := range u.allLinks() {
+		usage = append(usage, LinkUsage{
+			City1:  l.city1,
+			City2:  l.city2,
+			Routes: l.city1.routes[l.city2],
+			Count:  counts[l],
+		})
+	}
+	sort.SliceStable(usage, func(i, j int) bool { return usage[i].Count > usage[j].Count })
+	return
+}
+
+// Returns a destination's fewest-hops path if fewestHops is true and otherwise
+// its shortest path, or an error if its cities aren't connected.
+func destPath(d *Dest, fewestHops bool) (*Path, error) {
+	p := d.City1.shortestDist[d.City2]
+	if fewestHops {
+		p = d.City1.fewestHops[d.City2]
+	}
+	if p == nil {
+		return nil, fmt.Errorf("no path from %q to %q", d.City1.Name, d.City2.Name)
+	}
+	return p, nil
+}
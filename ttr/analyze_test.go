@@ -0,0 +1,142 @@
+package ttr
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNewRegionsFromRegionEntries(t *testing.T) {
+	u := newUnivNoPaths(mustLoadRouteEntriesFromString("alpha - bravo: 1 wild\nbravo - charlie: 1 wild\n"))
+
+	regions, err := newRegionsFromRegionEntries(u, []regionEnt{
+		{"east", []string{"alpha", "bravo"}},
+		{"west", []string{"charlie"}},
+	})
+	if err != nil {
+		t.Fatalf("got error creating regions: %s", err)
+	}
+	if len(regions) != 2 {
+		t.Fatalf("expected 2 regions but got %d", len(regions))
+	}
+	if !regions[0].cities[u.cityByName["bravo"]] || regions[0].cities[u.cityByName["charlie"]] {
+		t.Errorf("region %q has wrong cities", regions[0].Name)
+	}
+
+	if _, err := newRegionsFromRegionEntries(u, []regionEnt{{"east", []string{"delta"}}}); err == nil {
+		t.Errorf("expected error for unknown city but got none")
+	}
+}
+
+func TestAnalyzeDests(t *testing.T) {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 1 wild
+		bravo - charlie: 1 wild
+		charlie - delta: 1 wild
+		delta - echo: 8 wild
+	`))
+	alpha := u.cityByName["alpha"]
+	charlie := u.cityByName["charlie"]
+	delta := u.cityByName["delta"]
+	echo := u.cityByName["echo"]
+	regions, err := newRegionsFromRegionEntries(u, []regionEnt{
+		{"west", []string{"alpha", "bravo"}},
+		{"east", []string{"echo"}},
+	})
+	if err != nil {
+		t.Fatalf("got error creating regions: %s", err)
+	}
+
+	dests := []*Dest{
+		newDest(alpha, charlie, 2),
+		newDest(alpha, delta, 3),
+		newDest(charlie, echo, 9),
+	}
+	st, err := AnalyzeDests(u, dests, regions)
+	if err != nil {
+		t.Fatalf("got error analyzing destinations: %s", err)
+	}
+
+	if st.valueCounts[2] != 1 || st.valueCounts[3] != 1 || st.valueCounts[9] != 1 {
+		t.Errorf("got unexpected value counts %v", st.valueCounts)
+	}
+	if st.cityCounts[alpha] != 2 || st.cityCounts[charlie] != 2 || st.cityCounts[u.cityByName["bravo"]] != 0 {
+		t.Errorf("got unexpected city counts %v", st.cityCounts)
+	}
+	if st.regionCounts[0] != 2 || st.regionCounts[1] != 1 {
+		t.Errorf("got unexpected region counts %v", st.regionCounts)
+	}
+
+	// alpha–charlie and alpha–delta share two links, alpha–delta and
+	// charlie–echo share one, and alpha–charlie and charlie–echo share none.
+	if st.numPairs != 3 || st.numOverlapping != 2 || st.totalShared != 3 {
+		t.Errorf("expected 3 pairs, 2 overlapping, 3 shared but got %d, %d, %d", st.numPairs, st.numOverlapping,
+			st.totalShared)
+	}
+	if st.maxShared != 2 || st.maxSharedDests[0] != dests[0] || st.maxSharedDests[1] != dests[1] {
+		t.Errorf("got unexpected maximum overlap %d (%v)", st.maxShared, st.maxSharedDests)
+	}
+
+	sum := st.Summary()
+	if sum.NumDests != 3 || len(sum.Values) != 3 || sum.Values[0] != (ValueCount{2, 1}) {
+		t.Errorf("got unexpected summary values %d, %v", sum.NumDests, sum.Values)
+	}
+	if sum.Cities[0] != (NameCount{"alpha", 2}) || sum.Cities[1] != (NameCount{"charlie", 2}) {
+		t.Errorf("got unexpected summary cities %v", sum.Cities)
+	}
+	if sum.Buckets[0] != (NameCount{"short (1–9)", 3}) || sum.Regions[1] != (NameCount{"east", 1}) {
+		t.Errorf("got unexpected summary buckets %v and regions %v", sum.Buckets, sum.Regions)
+	}
+	if exp := []string{"alpha – charlie", "alpha – delta"}; strings.Join(sum.Overlap.MaxSharedDests, ", ") !=
+		strings.Join(exp, ", ") {
+		t.Errorf("expected most overlap %v but got %v", exp, sum.Overlap.MaxSharedDests)
+	}
+}
+
+func TestCountLinkUsage(t *testing.T) {
+
+	// The fewest-hops path from alpha to delta is the direct route; the shortest
+	// path is via bravo and charlie.
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 1 wild
+		bravo - charlie: 1 red
+		charlie - delta: 1 blue
+		alpha - delta: 6 wild
+	`))
+	dests := []*Dest{
+		newDest(u.cityByName["alpha"], u.cityByName["delta"], 3),
+		newDest(u.cityByName["bravo"], u.cityByName["delta"], 2),
+	}
+
+	check := func(fewestHops bool, exp []string) {
+		var got []string
+		usage, err := CountLinkUsage(u, dests, fewestHops)
+		if err != nil {
+			t.Fatalf("got error counting usage: %s", err)
+		}
+		for _, lu := range usage {
+			got = append(got, fmt.Sprintf("%s–%s %d", lu.City1.Name, lu.City2.Name, lu.Count))
+		}
+		if strings.Join(got, ", ") != strings.Join(exp, ", ") {
+			t.Errorf("with fewestHops=%v, expected %v but got %v", fewestHops, exp, got)
+		}
+	}
+	check(false, []string{"bravo–charlie 2", "charlie–delta 2", "alpha–bravo 1", "alpha–delta 0"})
+	check(true, []string{"alpha–delta 1", "bravo–charlie 1", "charlie–delta 1", "alpha–bravo 0"})
+}
+
+func TestAnalyzeDestsUnconnected(t *testing.T) {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 1 wild
+		charlie - delta: 1 wild
+	`))
+	dests := []*Dest{newDest(u.cityByName["alpha"], u.cityByName["delta"], 5)}
+	if _, err := AnalyzeDests(u, dests, nil); err == nil {
+		t.Errorf("expected error analyzing destinations but got none")
+	}
+	for _, fewestHops := range []bool{false, true} {
+		if _, err := CountLinkUsage(u, dests, fewestHops); err == nil {
+			t.Errorf("with fewestHops=%v, expected error counting usage but got none", fewestHops)
+		}
+	}
+}
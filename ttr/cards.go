@@ -0,0 +1,134 @@
+package ttr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Dimensions of a printed destination card, in millimeters. These match the
+// official cards.
+const (
+	cardWidth  = 44.0
+	cardHeight = 68.0
+)
+
+// The smallest margin, in millimeters, to leave around the card grid on a
+// page. Cut marks go in the margin, and most home printers can't print closer
+// than a few millimeters to the edge of the paper.
+const minPageMargin = 10.0
+
+type PageSize struct {
+	name   string
+	width  float64
+	height float64
+}
+
+var pageSizes = []PageSize{
+	{name: "a4", width: 210, height: 297},
+	{name: "letter", width: 215.9, height: 279.4},
+}
+
+func FindPageSize(name string) (PageSize, error) {
+	for _, ps := range pageSizes {
+		if ps.name == name {
+			return ps, nil
+		}
+	}
+	return PageSize{}, fmt.Errorf("invalid page size %q", name)
+}
+
+// A card layout is a grid of cards centered on a page.
+type CardLayout struct {
+	page PageSize
+	cols int
+	rows int
+	left float64
+	top  float64
+}
+
+func NewCardLayout(page PageSize) CardLayout {
+	l := CardLayout{page: page}
+	l.cols = int((page.width - 2*minPageMargin) / cardWidth)
+	l.rows = int((page.height - 2*minPageMargin) / cardHeight)
+	l.left = (page.width - float64(l.cols)*cardWidth) / 2
+	l.top = (page.height - float64(l.rows)*cardHeight) / 2
+	return l
+}
+
+func (l CardLayout) CardsPerPage() int {
+	return l.cols * l.rows
+}
+
+// Splits destinations into pages.
+func (l CardLayout) Paginate(dests []*Dest) (pages [][]*Dest) {
+	n := l.CardsPerPage()
+	for len(dests) > n {
+		pages = append(pages, dests[:n])
+		dests = dests[n:]
+	}
+	if len(dests) > 0 {
+		pages = append(pages, dests)
+	}
+	return
+}
+
+// Writes one SVG page of destination cards. There must be no more destinations
+// than fit on a page. If the universe is non-nil then each card has a mini-map
+// of the universe, which must be located.
+func WriteCardPage(w io.Writer, l CardLayout, dests []*Dest, u *Univ) error {
+	if len(dests) > l.CardsPerPage() {
+		panic("too many cards for page")
+	}
+
+	var buf bytes.Buffer
+	svgStart(&buf, l.page.width, l.page.height)
+	l.writeCutMarks(&buf)
+	for i, d := range dests {
+		x := l.left + float64(i%l.cols)*cardWidth
+		y := l.top + float64(i/l.cols)*cardHeight
+		writeCard(&buf, x, y, d, u)
+	}
+	svgEnd(&buf)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Draws cut marks in the margins, in line with every edge of the card grid.
+func (l CardLayout) writeCutMarks(buf *bytes.Buffer) {
+	const gap = 2.0
+	const length = 6.0
+	const style = "stroke:black;stroke-width:0.2"
+	right := l.left + float64(l.cols)*cardWidth
+	bottom := l.top + float64(l.rows)*cardHeight
+	for i := 0; i <= l.cols; i++ {
+		x := l.left + float64(i)*cardWidth
+		svgLine(buf, x, l.top-gap-length, x, l.top-gap, style)
+		svgLine(buf, x, bottom+gap, x, bottom+gap+length, style)
+	}
+	for i := 0; i <= l.rows; i++ {
+		y := l.top + float64(i)*cardHeight
+		svgLine(buf, l.left-gap-length, y, l.left-gap, y, style)
+		svgLine(buf, right+gap, y, right+gap+length, y, style)
+	}
+}
+
+// Draws a single card with its top-left corner at (x, y), with a mini-map if
+// the universe is non-nil.
+func writeCard(buf *bytes.Buffer, x, y float64, d *Dest, u *Univ) {
+	const inset = 2.0
+	const textStyle = "font-family:sans-serif;fill:black"
+	cx := x + cardWidth/2
+	svgRect(buf, x+inset, y+inset, cardWidth-2*inset, cardHeight-2*inset, 3,
+		"fill:none;stroke:gray;stroke-width:0.3")
+	svgText(buf, cx, y+14, 4.5, textStyle, d.City1.Name)
+	svgText(buf, cx, y+21, 3, textStyle+";font-style:italic", "to")
+	svgText(buf, cx, y+28, 4.5, textStyle, d.City2.Name)
+	if u != nil {
+		writeMiniMap(buf, u, x+4, y+31, cardWidth-8, 21, d.City1, d.City2)
+	}
+	svgCircle(buf, x+cardWidth-10, y+cardHeight-10, 5, "fill:white;stroke:black;stroke-width:0.4")
+	svgText(buf, x+cardWidth-10, y+cardHeight-8.2, 5, textStyle+";font-weight:bold", strconv.Itoa(d.Value))
+}
@@ -0,0 +1,80 @@
+package ttr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestNewCardLayout(t *testing.T) {
+	check := func(name string, expCols, expRows int) {
+		page, err := FindPageSize(name)
+		if err != nil {
+			t.Fatalf("got error finding page size %q: %s", name, err)
+		}
+		l := NewCardLayout(page)
+		if l.cols != expCols || l.rows != expRows {
+			t.Errorf("with %s, expected %dx%d cards but got %dx%d", name, expCols, expRows, l.cols, l.rows)
+		}
+		if l.left < minPageMargin || l.top < minPageMargin {
+			t.Errorf("with %s, margins %v, %v are too small", name, l.left, l.top)
+		}
+	}
+	check("a4", 4, 4)
+	check("letter", 4, 3)
+
+	if _, err := FindPageSize("legal"); err == nil {
+		t.Errorf("expected error for unknown page size but got none")
+	}
+}
+
+func TestCardLayoutPaginate(t *testing.T) {
+	l := CardLayout{cols: 2, rows: 2}
+	c1 := newCity("alpha")
+	c2 := newCity("bravo")
+	var dests []*Dest
+	for i := 0; i < 9; i++ {
+		dests = append(dests, newDest(c1, c2, i))
+	}
+	pages := l.Paginate(dests)
+	if len(pages) != 3 || len(pages[0]) != 4 || len(pages[1]) != 4 || len(pages[2]) != 1 {
+		t.Errorf("got unexpected pagination %v", pages)
+	}
+	if pages := l.Paginate(nil); len(pages) != 0 {
+		t.Errorf("expected no pages but got %d", len(pages))
+	}
+}
+
+func TestWriteCardPage(t *testing.T) {
+	page, _ := FindPageSize("a4")
+	l := NewCardLayout(page)
+	dests := []*Dest{
+		newDest(newCity("Alpha & Omega"), newCity("<Bravo>"), 17),
+		newDest(newCity("Charlie"), newCity("Delta"), 4),
+	}
+	var buf bytes.Buffer
+	if err := WriteCardPage(&buf, l, dests, nil); err != nil {
+		t.Fatalf("got error writing page: %s", err)
+	}
+
+	// check: output is well-formed XML containing every card's text
+	var texts []string
+	dec := xml.NewDecoder(bytes.NewReader(buf.Bytes()))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("got error parsing SVG: %s", err)
+		}
+		if cd, ok := tok.(xml.CharData); ok && len(strings.TrimSpace(string(cd))) > 0 {
+			texts = append(texts, string(cd))
+		}
+	}
+	exp := []string{"Alpha & Omega", "to", "<Bravo>", "17", "Charlie", "to", "Delta", "4"}
+	if strings.Join(texts, "|") != strings.Join(exp, "|") {
+		t.Errorf("expected text %q but got %q", exp, texts)
+	}
+}
@@ -0,0 +1,128 @@
+package ttr
+
+import (
+	"fmt"
+)
+
+// Returns, among the shortest paths between two cities, the one whose routes
+// need train cards of the fewest distinct colors, breaking ties by fewest
+// hazards and then by fewest hops. Gray routes, which take any color, don't
+// count as a color. Where cities are connected by more than one route, the
+// path may use whichever route's color suits it best.
+func (u *Univ) FewestColorsPath(from, to string) (*Path, error) {
+	c1 := u.cityByName[from]
+	if c1 == nil {
+		return nil, fmt.Errorf("city %q doesn't exist", from)
+	}
+	c2 := u.cityByName[to]
+	if c2 == nil {
+		return nil, fmt.Errorf("city %q doesn't exist", to)
+	}
+	p := fewestColorsPath(c1, c2)
+	if p == nil {
+		return nil, fmt.Errorf("no path from %q to %q", from, to)
+	}
+	return p, nil
+}
+
+// Returns the path that FewestColorsPath describes, or nil if the cities aren't
+// connected.
+func fewestColorsPath(c1, c2 *City) *Path {
+	if c1.shortestDist[c2] == nil {
+		return nil
+	}
+	total := c1.shortestDist[c2].Dist
+	distTo := func(c *City) int { return c1.shortestDist[c].Dist }
+	distFrom := func(c *City) int { return c.shortestDist[c2].Dist }
+
+	// Search every shortest path depth first, extending a path only by hops that
+	// keep it on some shortest path to c2.
+	var best *Path
+	bestColors := 0
+	p := newPath(c1)
+	colors := make(map[string]int)
+	var search func(cur *City)
+	search = func(cur *City) {
+		if len(colors) > bestColors && best != nil {
+			return // can't beat the best path so far
+		}
+		if cur == c2 {
+			if best == nil || compInts(len(colors), bestColors, p.Hazards, best.Hazards, len(p.Routes),
+				len(best.Routes)) > 0 {
+				best = copyPath(p)
+				bestColors = len(colors)
+			}
+			return
+		}
+		for _, adj := range cur.adj {
+			for _, r := range cur.routes[adj] {
+				if distTo(cur)+r.Dist != distTo(adj) || distTo(adj)+distFrom(adj) != total {
+					continue
+				}
+				p.appendHop(adj, r)
+				if r.Color != "wild" {
+					colors[r.Color]++
+				}
+				search(adj)
+				if r.Color != "wild" {
+					if colors[r.Color]--; colors[r.Color] == 0 {
+						delete(colors, r.Color)
+					}
+				}
+				p.chopHop()
+			}
+		}
+	}
+	search(c1)
+	return best
+}
+
+// Returns a copy of the path through the same cities that, wherever two cities
+// are connected by more than one route of the same length, takes whichever
+// route leaves the path needing cards of the fewest distinct colors, breaking
+// ties by fewest hazards.
+func (p *Path) WithFewestColors() *Path {
+	var best *Path
+	bestColors := 0
+	q := newPath(p.Cities[0])
+	colors := make(map[string]int)
+	var search func(i int)
+	search = func(i int) {
+		if i == len(p.Routes) {
+			if best == nil || compInts(len(colors), bestColors, q.Hazards, best.Hazards) > 0 {
+				best = copyPath(q)
+				bestColors = len(colors)
+			}
+			return
+		}
+		for _, r := range p.Cities[i].routes[p.Cities[i+1]] {
+			if r.Dist != p.Routes[i].Dist {
+				continue
+			}
+			q.appendHop(p.Cities[i+1], r)
+			if r.Color != "wild" {
+				colors[r.Color]++
+			}
+			search(i + 1)
+			if r.Color != "wild" {
+				if colors[r.Color]--; colors[r.Color] == 0 {
+					delete(colors, r.Color)
+				}
+			}
+			q.chopHop()
+		}
+	}
+	search(0)
+	return best
+}
+
+// Returns the number of distinct colors of cards that the path needs, not
+// counting gray routes or locomotives.
+func (p *Path) NumCardColors() (n int) {
+	for _, cc := range p.CardsNeeded() {
+		if cc.Color != "wild" && cc.Color != "locomotive" {
+			n++
+		}
+	}
+	return
+}
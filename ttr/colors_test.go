@@ -0,0 +1,73 @@
+package ttr
+
+import (
+	"testing"
+)
+
+func TestFewestColorsPath(t *testing.T) {
+
+	// All paths from alpha to delta have length 4. Via bravo, the path can use
+	// red for both hops; via charlie it needs two colors.
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - charlie: 2 green
+		charlie - delta: 2 yellow
+		alpha - bravo: 2 red
+		bravo - delta: 2 blue, 2 red
+		alpha - echo: 5 wild
+	`))
+	p, err := u.FewestColorsPath("alpha", "delta")
+	if err != nil {
+		t.Fatalf("got error: %s", err)
+	}
+	if exp := `"alpha"–2,red–"bravo"–2,red–"delta"`; p.String() != exp {
+		t.Errorf("expected %s but got %s", exp, p)
+	}
+	if n := p.NumCardColors(); n != 1 {
+		t.Errorf("expected 1 color but got %d", n)
+	}
+
+	// A path of gray routes needs no particular color.
+	u = newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 2 red
+		bravo - delta: 2 blue
+		alpha - charlie: 3 wild
+		charlie - delta: 1 wild
+	`))
+	if p, _ = u.FewestColorsPath("alpha", "delta"); p.NumCardColors() != 0 || len(p.Routes) != 2 ||
+		p.Cities[1].Name != "charlie" {
+		t.Errorf("expected path via charlie but got %s", p)
+	}
+
+	if _, err = u.FewestColorsPath("alpha", "golf"); err == nil {
+		t.Errorf("expected error for unknown city but got none")
+	}
+
+	// Cities that aren't connected have no path and score zero.
+	u = newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 2 red
+		charlie - delta: 2 blue
+	`))
+	if _, err = u.FewestColorsPath("alpha", "delta"); err == nil {
+		t.Errorf("expected error for unconnected cities but got none")
+	}
+	if n := (colorsScorer{}).Score(u.cityByName["alpha"], u.cityByName["delta"]); n != 0 {
+		t.Errorf("expected score 0 for unconnected cities but got %d", n)
+	}
+}
+
+func TestPathWithFewestColors(t *testing.T) {
+
+	// The double route's red route matches the other hop, whichever route the
+	// path started with.
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 2 red
+		bravo - charlie: 2 blue, 2 red
+	`))
+	alpha, charlie := u.cityByName["alpha"], u.cityByName["charlie"]
+	if p := alpha.fewestHops[charlie].WithFewestColors(); p.String() != `"alpha"–2,red–"bravo"–2,red–"charlie"` {
+		t.Errorf("expected red path but got %s", p)
+	}
+	if p := charlie.fewestHops[alpha].WithFewestColors(); p.String() != `"charlie"–2,red–"bravo"–2,red–"alpha"` {
+		t.Errorf("expected red path but got %s", p)
+	}
+}
@@ -0,0 +1,279 @@
+package ttr
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Constraints on a deck of destinations, for the "constrained" mode of
+// GenerateDestinations. A zero field means no constraint unless noted
+// otherwise.
+type DeckConstraints struct {
+	MinValue int
+	MaxValue int
+
+	// The fewest hops between a destination's cities. It's 2 unless MinHopsSet
+	// is true, so that a caller can ask for 0 or 1.
+	MinHops    int
+	MinHopsSet bool
+
+	// The most destinations that may include any one city. The default is the
+	// number of cities adjacent to the city, as in the other modes.
+	MaxPerCity int
+
+	RequiredCities []string    // each must be in at least one destination
+	ForbiddenPairs [][2]string // city pairs, in either order, not to use
+
+	// The fewest destinations whose values fall within the long value bucket.
+	MinLong int
+
+	// If true then no destination's shortest path lies entirely within another
+	// destination's shortest path, so that no track completes two destinations
+	// for free.
+	NoNestedPaths bool
+}
+
+// An error reporting that a deck constraint can't be satisfied. The constraint
+// is named as by the make-dests command's flags, e.g., "max-per-city".
+type ConstraintError struct {
+	Constraint string
+	Msg        string
+}
+
+func (e *ConstraintError) Error() string {
+	return fmt.Sprintf("unsatisfiable constraint %s: %s", e.Constraint, e.Msg)
+}
+
+func newConstraintError(constraint, format string, a ...interface{}) *ConstraintError {
+	return &ConstraintError{Constraint: constraint, Msg: fmt.Sprintf(format, a...)}
+}
+
+// How many times makeDestsConstrained starts over before giving up.
+const maxDeckAttempts = 100
+
+// Makes n random destinations satisfying the given constraints, or returns a
+// *ConstraintError naming a constraint that can't be satisfied.
+func makeDestsConstrained(u *Univ, n int, rng *rand.Rand, sc Scorer, dc DeckConstraints) ([]*Dest, error) {
+
+	for _, name := range dc.RequiredCities {
+		if u.cityByName[name] == nil {
+			return nil, fmt.Errorf("city %q doesn't exist", name)
+		}
+	}
+	forbidden := make(map[link]bool)
+	for _, pair := range dc.ForbiddenPairs {
+		for _, name := range pair {
+			if u.cityByName[name] == nil {
+				return nil, fmt.Errorf("city %q doesn't exist", name)
+			}
+		}
+		forbidden[newLink(u.cityByName[pair[0]], u.cityByName[pair[1]])] = true
+	}
+	minHops := 2
+	if dc.MinHopsSet {
+		minHops = dc.MinHops
+	}
+
+	// Gather the candidate destinations, counting how many city pairs survive
+	// each constraint so that the first constraint to leave too few can be
+	// blamed.
+	type stage struct {
+		constraint string
+		ok         func(d *Dest, p *Path) bool
+		survivors  int
+	}
+	stages := []*stage{
+		{constraint: "min-hops", ok: func(d *Dest, p *Path) bool { return len(p.Routes) >= minHops }},
+		{constraint: "min-value", ok: func(d *Dest, p *Path) bool { return d.Value >= dc.MinValue }},
+		{constraint: "max-value", ok: func(d *Dest, p *Path) bool { return dc.MaxValue == 0 || d.Value <= dc.MaxValue }},
+		{constraint: "forbid", ok: func(d *Dest, p *Path) bool { return !forbidden[newLink(d.City1, d.City2)] }},
+	}
+	var candidates []*Dest
+	allCities := u.Cities()
+	for i, c1 := range allCities {
+	nextPair:
+		for _, c2 := range allCities[i+1:] {
+			p := c1.fewestHops[c2]
+			if p == nil {
+				continue
+			}
+			d := newDest(c1, c2, sc.Score(c1, c2))
+			for _, st := range stages {
+				if !st.ok(d, p) {
+					continue nextPair
+				}
+				st.survivors++
+			}
+			candidates = append(candidates, d)
+		}
+	}
+	if len(candidates) < n {
+		for _, st := range stages {
+			if st.survivors < n {
+				return nil, newConstraintError(st.constraint, "only %d city pairs are allowed, too few for %d destinations",
+					st.survivors, n)
+			}
+		}
+	}
+
+	// Check the deck-wide constraints that can be judged up front.
+	capOf := func(c *City) int {
+		if dc.MaxPerCity > 0 {
+			return dc.MaxPerCity
+		}
+		return len(c.routes)
+	}
+	degrees := make(map[*City]int)
+	long := DefaultValueBuckets()[2]
+	isLong := func(d *Dest) bool { return long.Min <= d.Value && d.Value <= long.Max }
+	numLong := 0
+	for _, d := range candidates {
+		degrees[d.City1]++
+		degrees[d.City2]++
+		if isLong(d) {
+			numLong++
+		}
+	}
+	if len(dc.RequiredCities) > 2*n {
+		return nil, newConstraintError("require", "%d cities can't all be in %d destinations", len(dc.RequiredCities), n)
+	}
+	for _, name := range dc.RequiredCities {
+		if degrees[u.cityByName[name]] == 0 {
+			return nil, newConstraintError("require", "no allowed destination includes %q", name)
+		}
+	}
+	if dc.MinLong > n {
+		return nil, newConstraintError("min-long", "%d long destinations can't fit in %d destinations", dc.MinLong, n)
+	}
+	if numLong < dc.MinLong {
+		return nil, newConstraintError("min-long", "only %d allowed destinations are long (values %s), fewer than %d",
+			numLong, long.rangeString(), dc.MinLong)
+	}
+	capacity := 0
+	for c, deg := range degrees {
+		capacity += minInt(deg, capOf(c))
+	}
+	if capacity/2 < n {
+		return nil, newConstraintError("max-per-city", "the per-city limits allow at most %d destinations, fewer than %d",
+			capacity/2, n)
+	}
+
+	// Build decks greedily from shuffled candidates, starting over whenever a
+	// deck gets stuck.
+	var links []map[link]bool
+	if dc.NoNestedPaths {
+		for _, d := range candidates {
+			links = append(links, d.City1.shortestDist[d.City2].links())
+		}
+	}
+	failures := make(map[string]int)
+	lastFailure := make(map[string]*ConstraintError)
+	for attempt := 0; attempt < maxDeckAttempts; attempt++ {
+		order := rng.Perm(len(candidates))
+		var picked []int
+		counts := make(map[*City]int)
+		used := make(map[int]bool)
+		numLong := 0
+		rejectedByPaths := false // whether NoNestedPaths rejected a destination during the latest pick
+
+		fits := func(i int) bool {
+			d := candidates[i]
+			if used[i] || counts[d.City1] >= capOf(d.City1) || counts[d.City2] >= capOf(d.City2) {
+				return false
+			}
+			if dc.NoNestedPaths {
+				for _, j := range picked {
+					if isLinkSubset(links[i], links[j]) || isLinkSubset(links[j], links[i]) {
+						rejectedByPaths = true
+						return false
+					}
+				}
+			}
+			return true
+		}
+		pick := func(want func(d *Dest) bool) bool {
+			rejectedByPaths = false
+			for _, i := range order {
+				if want(candidates[i]) && fits(i) {
+					picked = append(picked, i)
+					used[i] = true
+					counts[candidates[i].City1]++
+					counts[candidates[i].City2]++
+					if isLong(candidates[i]) {
+						numLong++
+					}
+					return true
+				}
+			}
+			return false
+		}
+
+		var failure *ConstraintError
+		for _, name := range dc.RequiredCities {
+			c := u.cityByName[name]
+			if counts[c] > 0 {
+				continue
+			}
+			if !pick(func(d *Dest) bool { return d.City1 == c || d.City2 == c }) {
+				failure = newConstraintError("require", "no destination including %q fits with the others", name)
+				break
+			}
+		}
+		for failure == nil && numLong < dc.MinLong {
+			if !pick(isLong) {
+				failure = newConstraintError("min-long", "no more long destinations fit with the others")
+			}
+		}
+		for failure == nil && len(picked) < n {
+			if !pick(func(d *Dest) bool { return true }) {
+				if rejectedByPaths {
+					failure = newConstraintError("no-nested-paths", "only %d destinations with unnested paths fit together",
+						len(picked))
+				} else {
+					failure = newConstraintError("max-per-city", "only %d destinations fit within the per-city limits",
+						len(picked))
+				}
+			}
+		}
+
+		if failure != nil {
+			failures[failure.Constraint]++
+			lastFailure[failure.Constraint] = failure
+			continue
+		}
+
+		dests := make([]*Dest, len(picked))
+		for j, i := range picked {
+			d := *candidates[i]
+			if rng.Intn(2) == 1 {
+				d.City1, d.City2 = d.City2, d.City1
+			}
+			dests[j] = &d
+		}
+		rng.Shuffle(len(dests), func(i, j int) {
+			dests[i], dests[j] = dests[j], dests[i]
+		})
+		return dests, nil
+	}
+
+	// Blame the constraint that most often got in the way.
+	var worst *ConstraintError
+	for constraint, e := range lastFailure {
+		if worst == nil || failures[constraint] > failures[worst.Constraint] ||
+			(failures[constraint] == failures[worst.Constraint] && constraint < worst.Constraint) {
+			worst = e
+		}
+	}
+	worst.Msg = fmt.Sprintf("%s (gave up after %d attempts)", worst.Msg, maxDeckAttempts)
+	return nil, worst
+}
+
+// Returns whether every link in a is also in b.
+func isLinkSubset(a, b map[link]bool) bool {
+	for l := range a {
+		if !b[l] {
+			return false
+		}
+	}
+	return true
+}
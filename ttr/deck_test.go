@@ -0,0 +1,176 @@
+package ttr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestMakeDestsConstrained(t *testing.T) {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromFile("../routes.dat"))
+	dc := DeckConstraints{
+		MinValue:       5,
+		MaxValue:       20,
+		MinHops:        3,
+		MinHopsSet:     true,
+		MaxPerCity:     3,
+		RequiredCities: []string{"Miami", "Vancouver"},
+		ForbiddenPairs: [][2]string{{"Miami", "Seattle"}, {"Boston", "Vancouver"}},
+		MinLong:        4,
+		NoNestedPaths:  true,
+	}
+	dests, err := makeDestsConstrained(u, 20, rand.New(rand.NewSource(42)), fewestHopsScorer{}, dc)
+	if err != nil {
+		t.Fatalf("got error making destinations: %s", err)
+	}
+	if len(dests) != 20 {
+		t.Fatalf("expected 20 destinations but got %d", len(dests))
+	}
+
+	numLong := 0
+	for i, d := range dests {
+		name := d.City1.Name + " – " + d.City2.Name
+		if !isDestUnique(dests[:i], d) {
+			t.Errorf("destination %s is duplicated", name)
+		}
+		if d.Value < dc.MinValue || d.Value > dc.MaxValue {
+			t.Errorf("destination %s has value %d", name, d.Value)
+		}
+		if hops := len(d.City1.fewestHops[d.City2].Routes); hops < dc.MinHops {
+			t.Errorf("destination %s is %d hops", name, hops)
+		}
+		for _, pair := range dc.ForbiddenPairs {
+			if newLink(d.City1, d.City2) == newLink(u.cityByName[pair[0]], u.cityByName[pair[1]]) {
+				t.Errorf("destination %s is forbidden", name)
+			}
+		}
+		if d.Value >= 16 {
+			numLong++
+		}
+		for _, other := range dests[:i] {
+			l1 := d.City1.shortestDist[d.City2].links()
+			l2 := other.City1.shortestDist[other.City2].links()
+			if isLinkSubset(l1, l2) || isLinkSubset(l2, l1) {
+				t.Errorf("destinations %s and %s have overlapping paths", name, other.City1.Name+" – "+other.City2.Name)
+			}
+		}
+	}
+	for _, c := range u.Cities() {
+		if n := countCityInDests(dests, c); n > dc.MaxPerCity {
+			t.Errorf("city %q is in %d destinations", c.Name, n)
+		}
+	}
+	for _, name := range dc.RequiredCities {
+		if countCityInDests(dests, u.cityByName[name]) == 0 {
+			t.Errorf("required city %q is in no destination", name)
+		}
+	}
+	if numLong < dc.MinLong {
+		t.Errorf("expected at least %d long destinations but got %d", dc.MinLong, numLong)
+	}
+}
+
+func TestMakeDestsConstrainedUnsatisfiable(t *testing.T) {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromFile("../routes.dat"))
+
+	type tc struct {
+		n          int
+		dc         DeckConstraints
+		constraint string
+	}
+	for _, c := range []tc{
+		{30, DeckConstraints{MinHops: 9, MinHopsSet: true}, "min-hops"},
+		{30, DeckConstraints{MinValue: 25}, "min-value"},
+		{30, DeckConstraints{MaxValue: 2}, "max-value"},
+		{30, DeckConstraints{MaxPerCity: 1}, "max-per-city"},
+		{2, DeckConstraints{RequiredCities: []string{"Miami", "Boston", "Denver", "Dallas", "Omaha"}}, "require"},
+		{10, DeckConstraints{MinValue: 13, RequiredCities: []string{"Miami", "Omaha"}}, "require"},
+		{30, DeckConstraints{MaxValue: 15, MinLong: 1}, "min-long"},
+		{30, DeckConstraints{MinLong: 31}, "min-long"},
+		{40, DeckConstraints{MinHops: 6, MinHopsSet: true, MaxPerCity: 35, NoNestedPaths: true}, "no-nested-paths"},
+	} {
+		_, err := makeDestsConstrained(u, c.n, rand.New(rand.NewSource(42)), fewestHopsScorer{}, c.dc)
+		cerr, ok := err.(*ConstraintError)
+		if !ok {
+			t.Errorf("with %+v, expected constraint error but got %v", c.dc, err)
+			continue
+		}
+		if cerr.Constraint != c.constraint {
+			t.Errorf("with %+v, expected unsatisfiable %s but got %q", c.dc, c.constraint, cerr)
+		}
+	}
+
+	if _, err := makeDestsConstrained(u, 1, rand.New(rand.NewSource(42)), fewestHopsScorer{},
+		DeckConstraints{RequiredCities: []string{"Atlantis"}}); err == nil {
+		t.Errorf("expected error for unknown city but got none")
+	}
+}
+
+// Two constraints both prune candidates, but only one is to blame. Every
+// allowed destination includes alpha or bravo, so with one destination per
+// city no deck has three, with or without NoNestedPaths. NoNestedPaths still
+// rejects some destinations along the way, since bravo – charlie's and bravo –
+// delta's paths lie within alpha – echo's and alpha – foxtrot's.
+func TestMakeDestsConstrainedBlame(t *testing.T) {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 1 red
+		bravo - charlie: 1 red
+		charlie - delta: 1 red
+		delta - echo: 1 red
+		delta - foxtrot: 1 red
+		bravo - golf: 1 red
+	`))
+	allowed := map[link]bool{}
+	for _, pair := range [][2]string{
+		{"alpha", "echo"}, {"alpha", "foxtrot"}, {"bravo", "charlie"}, {"bravo", "delta"}, {"bravo", "golf"},
+	} {
+		allowed[newLink(u.cityByName[pair[0]], u.cityByName[pair[1]])] = true
+	}
+	dc := DeckConstraints{MinHops: 1, MinHopsSet: true, MaxPerCity: 1, NoNestedPaths: true}
+	cities := u.Cities()
+	for i, c1 := range cities {
+		for _, c2 := range cities[i+1:] {
+			if !allowed[newLink(c1, c2)] {
+				dc.ForbiddenPairs = append(dc.ForbiddenPairs, [2]string{c1.Name, c2.Name})
+			}
+		}
+	}
+
+	_, err := makeDestsConstrained(u, 3, rand.New(rand.NewSource(42)), fewestHopsScorer{}, dc)
+	if cerr, ok := err.(*ConstraintError); !ok || cerr.Constraint != "max-per-city" {
+		t.Errorf("expected unsatisfiable max-per-city but got %v", err)
+	}
+}
+
+// On a line of three cities, alpha – bravo's path lies within alpha – charlie's,
+// and the default minimum of two hops leaves only one city pair.
+func TestMakeDestsConstrainedPaths(t *testing.T) {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 1 red
+		bravo - charlie: 1 blue
+	`))
+
+	type tc struct {
+		dc         DeckConstraints
+		constraint string // or empty if the deck can be made
+	}
+	for _, c := range []tc{
+		{DeckConstraints{MaxPerCity: 2}, "min-hops"},
+		{DeckConstraints{MaxPerCity: 2, MinHops: 1}, "min-hops"}, // MinHopsSet is false
+		{DeckConstraints{MaxPerCity: 2, MinHops: 1, MinHopsSet: true}, ""},
+		{DeckConstraints{MaxPerCity: 2, MinHops: 0, MinHopsSet: true}, ""},
+		{DeckConstraints{MaxPerCity: 2, MinHops: 1, MinHopsSet: true, NoNestedPaths: true}, "no-nested-paths"},
+	} {
+		dests, err := makeDestsConstrained(u, 3, rand.New(rand.NewSource(42)), fewestHopsScorer{}, c.dc)
+		if c.constraint == "" {
+			if err != nil {
+				t.Errorf("with %+v, got error making destinations: %s", c.dc, err)
+			} else if len(dests) != 3 {
+				t.Errorf("with %+v, expected 3 destinations but got %d", c.dc, len(dests))
+			}
+			continue
+		}
+		if cerr, ok := err.(*ConstraintError); !ok || cerr.Constraint != c.constraint {
+			t.Errorf("with %+v, expected unsatisfiable %s but got %v", c.dc, c.constraint, err)
+		}
+	}
+}
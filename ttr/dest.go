@@ -0,0 +1,424 @@
+package ttr
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// A destination is a pair of cities worth a number of points to the player who
+// connects them.
+type Dest struct {
+	City1 *City
+	City2 *City
+	Value int
+}
+
+func countCityInDests(dests []*Dest, c *City) (n int) {
+	for _, d := range dests {
+		if d.City1 == c || d.City2 == c {
+			n++
+		}
+	}
+	return
+}
+
+func isDestUnique(all []*Dest, d *Dest) bool {
+	for _, x := range all {
+		if x.Equals(d) {
+			return false
+		}
+	}
+	return true
+}
+
+// Options for GenerateDestinations.
+type GenerateOptions struct {
+	N    int   // number of destinations to make
+	Seed int64 // for the random-number generator
+
+	// How to choose city pairs: "equal", the default, to choose every pair with
+	// equal likelihood, "weighted" to match the weights of Buckets, or
+	// "constrained" to satisfy Constraints.
+	Mode        string
+	Buckets     []ValueBucket // default: DefaultValueBuckets()
+	Constraints DeckConstraints
+
+	Scorer Scorer // default: the "fewest-hops" scorer
+
+	// The most city pairs to draw, in equal mode, before giving up. The default
+	// is 1000 per destination.
+	MaxAttempts int
+}
+
+// Makes a random set of destinations. The result depends only on the universe
+// and the options, so the same seed always makes the same destinations.
+func GenerateDestinations(u *Univ, opts GenerateOptions) ([]*Dest, error) {
+	sc := opts.Scorer
+	if sc == nil {
+		sc = fewestHopsScorer{}
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	switch opts.Mode {
+	case "", "equal":
+		maxAttempts := opts.MaxAttempts
+		if maxAttempts <= 0 {
+			maxAttempts = 1000 * opts.N
+		}
+		return makeDestsEqualLikely(u, opts.N, rng, sc, maxAttempts)
+	case "weighted":
+		buckets := opts.Buckets
+		if buckets == nil {
+			buckets = DefaultValueBuckets()
+		}
+		return makeDestsWeighted(u, opts.N, rng, buckets, sc)
+	case "constrained":
+		return makeDestsConstrained(u, opts.N, rng, sc, opts.Constraints)
+	}
+	return nil, fmt.Errorf("invalid mode %q", opts.Mode)
+}
+
+// Makes n random destinations, choosing city pairs with equal likelihood and
+// valuing them with the given scorer. The result depends only on the universe,
+// the scorer, and the state of the random-number generator. It's an error if
+// the map has too few suitable city pairs or if maxAttempts draws don't yield
+// enough destinations.
+func makeDestsEqualLikely(u *Univ, n int, rng *rand.Rand, sc Scorer, maxAttempts int) (dests []*Dest, err error) {
+
+	allCities := u.Cities()
+
+	// Every destination must be at least two hops, and each city may be in no
+	// more destinations than it has adjacent cities, so that it has a unique
+	// route for each destination. Find out up front whether that's enough.
+	fs := newFeasiblePairs(allCities)
+	if len(fs.pairs) < n {
+		return nil, fmt.Errorf("can't make %d destinations: only %d city pairs are at least two hops apart", n,
+			len(fs.pairs))
+	}
+	if fs.capacity < n {
+		return nil, fmt.Errorf("can't make %d destinations: of %d city pairs at least two hops apart, "+
+			"the per-city limits allow at most %d", n, len(fs.pairs), fs.capacity)
+	}
+
+	// choose cities with equal-likely randomness:
+	for attempt := 0; len(dests) < n; attempt++ {
+
+		if attempt == maxAttempts {
+			return nil, fmt.Errorf("gave up after %d attempts with %d of %d destinations "+
+				"(%d city pairs are at least two hops apart, and the per-city limits allow at most %d)", maxAttempts,
+				len(dests), n, len(fs.pairs), fs.capacity)
+		}
+
+		c1 := allCities[rng.Intn(len(allCities))]
+		partners := fs.partners[c1]
+		if len(partners) == 0 {
+			continue
+		}
+		// pick city #2 from those at least two hops away from city #1
+		c2 := partners[rng.Intn(len(partners))]
+
+		d := newDest(c1, c2, sc.Score(c1, c2))
+
+		// ensure that destination is unique:
+		if !isDestUnique(dests, d) {
+			continue
+		}
+
+		// ensure that both cities will have at least as many unique routes as
+		// destinations:
+		if countCityInDests(dests, d.City1) >= len(d.City1.routes) {
+			continue
+		}
+		if countCityInDests(dests, d.City2) >= len(d.City2.routes) {
+			continue
+		}
+
+		// destination is OK:
+		dests = append(dests, d)
+
+		// ensure that some pair can still be chosen, lest the loop spin forever:
+		if len(dests) < n && !fs.anyFits(dests) {
+			return nil, fmt.Errorf("stuck after %d of %d destinations: no remaining city pair fits within the per-city limits",
+				len(dests), n)
+		}
+	}
+
+	return
+}
+
+// The city pairs that may be destinations in equal mode: those at least two
+// hops apart.
+type feasiblePairs struct {
+	pairs    [][2]*City
+	partners map[*City][]*City // the cities paired with each city, ordered alphabetically
+
+	// An upper bound on the number of destinations that the per-city limits
+	// allow.
+	capacity int
+}
+
+func newFeasiblePairs(cities []*City) *feasiblePairs {
+	fs := &feasiblePairs{partners: make(map[*City][]*City)}
+	for i, c1 := range cities {
+		for _, c2 := range cities[i+1:] {
+			if p := c1.fewestHops[c2]; p != nil && len(p.Routes) >= 2 {
+				fs.pairs = append(fs.pairs, [2]*City{c1, c2})
+				fs.partners[c1] = append(fs.partners[c1], c2)
+				fs.partners[c2] = append(fs.partners[c2], c1)
+			}
+		}
+	}
+	slots := 0
+	for c, partners := range fs.partners {
+		slots += minInt(len(partners), len(c.routes))
+	}
+	fs.capacity = slots / 2
+	return fs
+}
+
+// Returns whether any pair may be added to the given destinations.
+func (fs *feasiblePairs) anyFits(dests []*Dest) bool {
+	counts := make(map[*City]int)
+	used := make(map[link]bool)
+	for _, d := range dests {
+		counts[d.City1]++
+		counts[d.City2]++
+		used[newLink(d.City1, d.City2)] = true
+	}
+	for _, pair := range fs.pairs {
+		c1, c2 := pair[0], pair[1]
+		if counts[c1] < len(c1.routes) && counts[c2] < len(c2.routes) && !used[newLink(c1, c2)] {
+			return true
+		}
+	}
+	return false
+}
+
+// A value bucket is a range of destination values plus the relative weight
+// with which to make destinations whose values fall within that range.
+type ValueBucket struct {
+	Name   string
+	Min    int
+	Max    int
+	Weight float64
+}
+
+func (b ValueBucket) rangeString() string {
+	if b.Max == math.MaxInt32 {
+		return fmt.Sprintf("%d+", b.Min)
+	}
+	return fmt.Sprintf("%d–%d", b.Min, b.Max)
+}
+
+// Returns the short, medium, and long buckets, with weights matching the
+// official destinations of the base game.
+func DefaultValueBuckets() []ValueBucket {
+	return []ValueBucket{
+		{Name: "short", Min: 1, Max: 9, Weight: 12},
+		{Name: "medium", Min: 10, Max: 15, Weight: 11},
+		{Name: "long", Min: 16, Max: math.MaxInt32, Weight: 7},
+	}
+}
+
+// Parses a bucket-weight specification, e.g., "short=10,medium=12,long=8".
+// Buckets not named in the specification have zero weight.
+func ParseValueBuckets(spec string) (buckets []ValueBucket, err error) {
+	buckets = DefaultValueBuckets()
+	for i := range buckets {
+		buckets[i].Weight = 0
+	}
+	for _, field := range strings.Split(spec, ",") {
+		index := strings.Index(field, "=")
+		if index == -1 {
+			return nil, fmt.Errorf("missing '=' in value bucket %q", field)
+		}
+		name := strings.TrimSpace(field[:index])
+		weightText := strings.TrimSpace(field[index+1:])
+		weight, err := strconv.ParseFloat(weightText, 64)
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("invalid weight %q for value bucket %q", weightText, name)
+		}
+		found := false
+		for i := range buckets {
+			if buckets[i].Name == name {
+				buckets[i].Weight = weight
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("unknown value bucket %q", name)
+		}
+	}
+	return
+}
+
+// Returns the short, medium, and long buckets, weighted by how many of the
+// given destinations fall within each bucket.
+func LearnValueBuckets(dests []*Dest) (buckets []ValueBucket) {
+	buckets = DefaultValueBuckets()
+	for i := range buckets {
+		buckets[i].Weight = 0
+	}
+	for _, d := range dests {
+		if i := findValueBucket(buckets, d.Value); i != -1 {
+			buckets[i].Weight++
+		}
+	}
+	return
+}
+
+// Returns the index of the bucket containing the given value, or -1 if no
+// bucket contains the value.
+func findValueBucket(buckets []ValueBucket, value int) int {
+	for i, b := range buckets {
+		if b.Min <= value && value <= b.Max {
+			return i
+		}
+	}
+	return -1
+}
+
+// Divides n destinations among the buckets in proportion to their weights,
+// using the largest-remainder method so that the counts sum to n.
+func apportionValueBuckets(buckets []ValueBucket, n int) (counts []int) {
+	var total float64
+	for _, b := range buckets {
+		total += b.Weight
+	}
+	counts = make([]int, len(buckets))
+	if total <= 0 {
+		return
+	}
+	remainders := make([]float64, len(buckets))
+	assigned := 0
+	for i, b := range buckets {
+		exact := float64(n) * b.Weight / total
+		counts[i] = int(exact)
+		remainders[i] = exact - float64(counts[i])
+		assigned += counts[i]
+	}
+	for ; assigned < n; assigned++ {
+		best := 0
+		for i := range remainders {
+			if remainders[i] > remainders[best] {
+				best = i
+			}
+		}
+		counts[best]++
+		remainders[best] = -1
+	}
+	return
+}
+
+// Makes n random destinations such that the distribution of destination
+// values, as determined by the given scorer, matches the relative weights of
+// the given buckets. Within a bucket, city pairs are chosen with equal
+// likelihood.
+func makeDestsWeighted(u *Univ, n int, rng *rand.Rand, buckets []ValueBucket, sc Scorer) (dests []*Dest, err error) {
+
+	// group all candidate destinations by bucket:
+	candidates := make([][]*Dest, len(buckets))
+	allCities := u.Cities()
+	for i, c1 := range allCities {
+		for _, c2 := range allCities[i+1:] {
+			p := c1.fewestHops[c2]
+			if p == nil || len(p.Routes) < 2 {
+				continue
+			}
+			value := sc.Score(c1, c2)
+			if j := findValueBucket(buckets, value); j != -1 {
+				candidates[j] = append(candidates[j], newDest(c1, c2, value))
+			}
+		}
+	}
+
+	// fill each bucket's quota, discarding candidates as they're drawn:
+	for i, quota := range apportionValueBuckets(buckets, n) {
+		for made := 0; made < quota; {
+			if len(candidates[i]) == 0 {
+				b := buckets[i]
+				return nil, fmt.Errorf("not enough %s destinations (values %s) to make %d of them", b.Name, b.rangeString(),
+					quota)
+			}
+			index := rng.Intn(len(candidates[i]))
+			d := candidates[i][index]
+			candidates[i][index] = candidates[i][len(candidates[i])-1]
+			candidates[i] = candidates[i][:len(candidates[i])-1]
+
+			// ensure that both cities will have at least as many unique routes as
+			// destinations:
+			if countCityInDests(dests, d.City1) >= len(d.City1.routes) {
+				continue
+			}
+			if countCityInDests(dests, d.City2) >= len(d.City2.routes) {
+				continue
+			}
+
+			if rng.Intn(2) == 1 {
+				d.City1, d.City2 = d.City2, d.City1
+			}
+			dests = append(dests, d)
+			made++
+		}
+	}
+
+	// mix the buckets together:
+	rng.Shuffle(len(dests), func(i, j int) {
+		dests[i], dests[j] = dests[j], dests[i]
+	})
+	return
+}
+
+func newDest(c1, c2 *City, value int) *Dest {
+	return &Dest{
+		City1: c1,
+		City2: c2,
+		Value: value,
+	}
+}
+
+// Loads destinations from a destination file. The destinations must be in the
+// given universe.
+func LoadDests(u *Univ, filename string) ([]*Dest, error) {
+	ents, err := loadDestEntriesFromFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	dests, err := newDestsFromDestEntries(u, ents)
+	return dests, withFile(err, filename)
+}
+
+// Writes destinations in destination-file format.
+func WriteDests(w io.Writer, dests []*Dest) error {
+	return writeDestEntries(w, destEntriesFromDests(dests))
+}
+
+func newDestsFromDestEntries(u *Univ, ents []destEnt) (s []*Dest, err error) {
+	// TODO: test
+	for _, ent := range ents {
+		c1 := u.cityByName[ent.name1]
+		if c1 == nil {
+			return nil, fmt.Errorf("error creating destination: city %q doesn't exist", ent.name1)
+		}
+		c2 := u.cityByName[ent.name2]
+		if c2 == nil {
+			return nil, fmt.Errorf("error creating destination: city %q doesn't exist", ent.name2)
+		}
+		s = append(s, newDest(c1, c2, ent.value))
+	}
+	return
+}
+
+func destEntriesFromDests(dests []*Dest) (ents []destEnt) {
+	for _, d := range dests {
+		ents = append(ents, destEnt{name1: d.City1.Name, name2: d.City2.Name, value: d.Value})
+	}
+	return
+}
+
+func (d *Dest) Equals(other *Dest) bool {
+	return (d.City1 == other.City1 && d.City2 == other.City2) || (d.City1 == other.City2 && d.City2 == other.City1)
+}
@@ -0,0 +1,235 @@
+package ttr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestDestEquals(t *testing.T) {
+
+	check := func(d1, d2 *Dest, exp bool) {
+		if d1.Equals(d2) != exp {
+			t.Errorf("with %v and %v, expected %v but got %v", d1, d2, exp, !exp)
+		}
+		if d2.Equals(d1) != exp {
+			t.Errorf("with %v and %v, expected %v but got %v", d2, d1, exp, !exp)
+		}
+	}
+
+	c1 := newCity("alpha")
+	c2 := newCity("bravo")
+	c3 := newCity("charlie")
+
+	// destinations are same:
+	check(newDest(c1, c2, 1), newDest(c1, c2, 1), true)
+	check(newDest(c1, c2, 1), newDest(c1, c2, 2), true)
+	check(newDest(c1, c2, 1), newDest(c2, c1, 1), true)
+	check(newDest(c1, c2, 1), newDest(c2, c1, 2), true)
+
+	// destinations are different:
+	check(newDest(c1, c3, 1), newDest(c1, c2, 1), false)
+	check(newDest(c1, c3, 1), newDest(c1, c2, 2), false)
+	check(newDest(c1, c3, 1), newDest(c2, c1, 1), false)
+	check(newDest(c1, c3, 1), newDest(c2, c1, 2), false)
+}
+
+func TestIsDestUnique(t *testing.T) {
+	c1 := newCity("alpha")
+	c2 := newCity("bravo")
+	c3 := newCity("charlie")
+	dests := []*Dest{
+		newDest(c1, c2, 1),
+		newDest(c1, c3, 1),
+	}
+	if isDestUnique(dests, newDest(c1, c2, 2)) {
+		t.Errorf("expected non-unique but got unique")
+	}
+	if !isDestUnique(dests, newDest(c2, c3, 3)) {
+		t.Errorf("expected unique but got non-unique")
+	}
+}
+
+func TestCountCityInDests(t *testing.T) {
+
+	check := func(dests []*Dest, c *City, exp int) {
+		got := countCityInDests(dests, c)
+		if exp != got {
+			t.Errorf("with %v, %v, expected %v but got %v", dests, c, exp, got)
+		}
+	}
+
+	c1 := newCity("alpha")
+	c2 := newCity("bravo")
+	c3 := newCity("charlie")
+	c4 := newCity("delta")
+	dests := []*Dest{
+		newDest(c1, c2, 1),
+		newDest(c1, c3, 1),
+	}
+	check(dests, c1, 2)
+	check(dests, c2, 1)
+	check(dests, c3, 1)
+	check(dests, c4, 0)
+}
+
+func TestMakeDestsEqualLikely(t *testing.T) {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromFile("../routes.dat"))
+
+	dests1, err := makeDestsEqualLikely(u, 30, rand.New(rand.NewSource(42)), fewestHopsScorer{}, 30000)
+	if err != nil {
+		t.Fatalf("got error making destinations: %s", err)
+	}
+	if len(dests1) != 30 {
+		t.Fatalf("expected 30 destinations but got %d", len(dests1))
+	}
+	for i, d := range dests1 {
+		if !isDestUnique(dests1[:i], d) {
+			t.Errorf("destination %q – %q is duplicated", d.City1.Name, d.City2.Name)
+		}
+		if len(d.City1.fewestHops[d.City2].Routes) < 2 {
+			t.Errorf("destination %q – %q is fewer than two hops", d.City1.Name, d.City2.Name)
+		}
+	}
+
+	// check: same seed yields same destinations
+	dests2, _ := makeDestsEqualLikely(u, 30, rand.New(rand.NewSource(42)), fewestHopsScorer{}, 30000)
+	for i := range dests1 {
+		d1, d2 := dests1[i], dests2[i]
+		if d1.City1 != d2.City1 || d1.City2 != d2.City2 || d1.Value != d2.Value {
+			t.Errorf("at index %d, expected %v but got %v", i, d1, d2)
+		}
+	}
+}
+
+func TestMakeDestsEqualLikelyInfeasible(t *testing.T) {
+
+	// a chain of four cities has three pairs at least two hops apart, but the
+	// end cities may each be in only one destination
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+alpha - bravo: 1 wild
+bravo - charlie: 1 wild
+charlie - delta: 1 wild
+`))
+
+	check := func(n, maxAttempts int, expErr bool) {
+		_, err := makeDestsEqualLikely(u, n, rand.New(rand.NewSource(42)), fewestHopsScorer{}, maxAttempts)
+		if expErr && err == nil {
+			t.Errorf("with n=%d and maxAttempts=%d, expected error but got none", n, maxAttempts)
+		} else if !expErr && err != nil {
+			t.Errorf("with n=%d and maxAttempts=%d, expected no error but got %q", n, maxAttempts, err)
+		}
+	}
+	check(1, 1000, false)
+	check(2, 1000, false)
+	check(3, 1000, true) // only two pairs fit within the per-city limits
+	check(4, 1000, true) // only three pairs exist
+	check(2, 1, true)    // too few attempts
+
+	// a map with two separate parts, where most cities can't reach delta or
+	// echo:
+	u = newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+alpha - bravo: 1 wild
+bravo - charlie: 1 wild
+charlie - foxtrot: 1 wild
+delta - echo: 1 wild
+`))
+	for seed := int64(0); seed < 20; seed++ {
+		dests, err := makeDestsEqualLikely(u, 1, rand.New(rand.NewSource(seed)), fewestHopsScorer{}, 1000)
+		if err != nil {
+			t.Fatalf("with seed %d, got error: %s", seed, err)
+		}
+		for _, d := range dests {
+			if d.City1.fewestHops[d.City2] == nil {
+				t.Errorf("with seed %d, got destination between unconnected cities %v", seed, d)
+			}
+		}
+	}
+
+	// a map with no pairs at least two hops apart:
+	u = newUnivFromRouteEntries(mustLoadRouteEntriesFromString("alpha - bravo: 1 wild\n"))
+	if _, err := makeDestsEqualLikely(u, 1, rand.New(rand.NewSource(42)), fewestHopsScorer{}, 1000); err == nil {
+		t.Errorf("expected error but got none")
+	}
+}
+
+func TestParseValueBuckets(t *testing.T) {
+	buckets, err := ParseValueBuckets("short=10, long=2.5")
+	if err != nil {
+		t.Fatalf("got error parsing buckets: %s", err)
+	}
+	exp := map[string]float64{"short": 10, "medium": 0, "long": 2.5}
+	for _, b := range buckets {
+		if b.Weight != exp[b.Name] {
+			t.Errorf("expected %s weight %v but got %v", b.Name, exp[b.Name], b.Weight)
+		}
+	}
+
+	for _, spec := range []string{"short", "short=x", "short=-1", "tiny=3"} {
+		if _, err := ParseValueBuckets(spec); err == nil {
+			t.Errorf("expected error parsing %q but got none", spec)
+		}
+	}
+}
+
+func TestLearnValueBuckets(t *testing.T) {
+	u := newUnivNoPaths(mustLoadRouteEntriesFromFile("../routes.dat"))
+	dests, err := newDestsFromDestEntries(u, mustLoadDestEntriesFromFile("../destinations.dat"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	buckets := LearnValueBuckets(dests)
+	exp := map[string]float64{"short": 12, "medium": 11, "long": 7}
+	for _, b := range buckets {
+		if b.Weight != exp[b.Name] {
+			t.Errorf("expected %s weight %v but got %v", b.Name, exp[b.Name], b.Weight)
+		}
+	}
+}
+
+func TestApportionValueBuckets(t *testing.T) {
+	check := func(weights []float64, n int, exp []int) {
+		var buckets []ValueBucket
+		for _, w := range weights {
+			buckets = append(buckets, ValueBucket{Weight: w})
+		}
+		got := apportionValueBuckets(buckets, n)
+		for i := range exp {
+			if got[i] != exp[i] {
+				t.Errorf("with weights %v and n=%d, expected %v but got %v", weights, n, exp, got)
+				break
+			}
+		}
+	}
+	check([]float64{12, 11, 7}, 30, []int{12, 11, 7})
+	check([]float64{12, 11, 7}, 15, []int{6, 6, 3})
+	check([]float64{1, 1, 1}, 10, []int{4, 3, 3})
+	check([]float64{0, 1, 0}, 5, []int{0, 5, 0})
+}
+
+func TestMakeDestsWeighted(t *testing.T) {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromFile("../routes.dat"))
+	buckets := DefaultValueBuckets()
+	dests, err := makeDestsWeighted(u, 30, rand.New(rand.NewSource(42)), buckets, fewestHopsScorer{})
+	if err != nil {
+		t.Fatalf("got error making destinations: %s", err)
+	}
+	counts := make([]int, len(buckets))
+	for i, d := range dests {
+		if !isDestUnique(dests[:i], d) {
+			t.Errorf("destination %q – %q is duplicated", d.City1.Name, d.City2.Name)
+		}
+		counts[findValueBucket(buckets, d.Value)]++
+	}
+	exp := apportionValueBuckets(buckets, 30)
+	for i := range exp {
+		if counts[i] != exp[i] {
+			t.Errorf("expected %d %s destination(s) but got %d", exp[i], buckets[i].Name, counts[i])
+		}
+	}
+
+	// check: error when a bucket can't be filled
+	buckets = []ValueBucket{{Name: "huge", Min: 100, Max: 200, Weight: 1}}
+	if _, err := makeDestsWeighted(u, 1, rand.New(rand.NewSource(42)), buckets, fewestHopsScorer{}); err == nil {
+		t.Errorf("expected error but got none")
+	}
+}
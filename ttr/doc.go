@@ -0,0 +1,20 @@
+// Package ttr models Ticket to Ride game boards and makes sets of destinations
+// for them.
+//
+// Load a board with LoadMap, which accepts the name of a bundled map, such as
+// "usa", or a map file. NewUniv turns the map into a universe, which finds the
+// best paths between every pair of cities:
+//
+//	m, err := ttr.LoadMap("usa")
+//	if err != nil {
+//		return err
+//	}
+//	u, err := ttr.NewUniv(m)
+//	if err != nil {
+//		return err
+//	}
+//	p, err := u.ShortestPath("Seattle", "New York")
+//
+// GenerateDestinations makes a random destination deck for a universe, and
+// the scorers returned by NewScorer value destinations in different ways.
+package ttr
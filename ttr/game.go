@@ -0,0 +1,340 @@
+package ttr
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// Scoring rules beyond route points.
+const (
+	LongestRouteBonus = 10 // for the player or players with the longest continuous path
+	StationPoints     = 4  // for each station a player didn't use, on boards with stations
+)
+
+// The state of a finished game: what each player claimed and held.
+type GameState struct {
+	Players []*PlayerState
+
+	// The number of stations each player has, as given by the map, or zero if
+	// the board has none.
+	StationsPerPlayer int
+}
+
+type PlayerState struct {
+	Name     string
+	Claims   []*Claim
+	Tickets  []*Dest
+	Stations []*City // cities where the player built a station
+}
+
+// Loads a game state from a file, which has a section for each player,
+// beginning with the player's name in brackets. Each line of a section is a
+// claimed route in route-file format, a destination ticket the player held,
+// prefixed with "ticket", or a city where the player built a station,
+// prefixed with "station", if the map gives players stations:
+//
+//	[Alice]
+//	Denver - Omaha: 4 pink
+//	ticket Denver - Miami: 14
+//	station Omaha
+//
+// No route may be claimed twice, and both routes of a double route may be
+// claimed only by different players in a game with enough players.
+func LoadGameState(u *Univ, filename string) (g *GameState, err error) {
+	err = loadFile(filename, func(r io.Reader) (err error) {
+		g, err = loadGameState(u, r)
+		return
+	})
+	return
+}
+
+func loadGameState(u *Univ, r io.Reader) (*GameState, error) {
+	g := &GameState{StationsPerPlayer: u.stations}
+	var pl *PlayerState
+	claimed := make(map[*Route]bool)
+	stationCities := make(map[*City]bool)
+	err := forEachLine(r, func(line string, lineNo int) error {
+		col := columnOf(line)
+		text := trimLeft(line)
+
+		// player header:
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return newParseError(lineNo, len(line)+1, "missing ']'")
+			}
+			name := strings.TrimSpace(text[1 : len(text)-1])
+			if name == "" {
+				return newParseError(lineNo, col(text), "missing player name")
+			}
+			for _, other := range g.Players {
+				if other.Name == name {
+					return newParseError(lineNo, col(text), "duplicate player %q", name)
+				}
+			}
+			pl = &PlayerState{Name: name}
+			g.Players = append(g.Players, pl)
+			return nil
+		}
+		if pl == nil {
+			return newParseError(lineNo, col(text), "missing player name, e.g., \"[Alice]\"")
+		}
+
+		// Blanking out the keyword, rather than chopping it, keeps the columns of
+		// parse errors right.
+		keyword := strings.Fields(text)[0]
+		blanked := line[:col(text)-1] + strings.Repeat(" ", len(keyword)) + text[len(keyword):]
+		switch keyword {
+		case "ticket":
+			ent, err := parseDestLine(blanked, lineNo)
+			if err != nil {
+				return err
+			}
+			c1, c2 := u.cityByName[ent.name1], u.cityByName[ent.name2]
+			if c1 == nil || c2 == nil {
+				return newParseError(lineNo, col(text), "destination %s - %s names an unknown city", ent.name1,
+					ent.name2)
+			}
+			pl.Tickets = append(pl.Tickets, newDest(c1, c2, ent.value))
+		case "station":
+			name := strings.TrimSpace(text[len(keyword):])
+			if g.StationsPerPlayer == 0 {
+				return newParseError(lineNo, col(text), "the map has no stations")
+			}
+			c := u.cityByName[name]
+			if c == nil {
+				return newParseError(lineNo, col(text), "city %q doesn't exist", name)
+			}
+			if stationCities[c] {
+				return newParseError(lineNo, col(text), "more than one station in %q", name)
+			}
+			stationCities[c] = true
+			if pl.Stations = append(pl.Stations, c); len(pl.Stations) > g.StationsPerPlayer {
+				return newParseError(lineNo, col(text), "player %q has more than %d stations", pl.Name,
+					g.StationsPerPlayer)
+			}
+		default:
+			ents, err := parseRouteLine(line, lineNo)
+			if err != nil {
+				return err
+			}
+			claims, err := newClaimsFromRouteEntries(u, ents, claimed)
+			if err != nil {
+				return err
+			}
+			pl.Claims = append(pl.Claims, claims...)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Double-route rules depend on the number of players, so check them once
+	// everything is loaded.
+	for _, pl := range g.Players {
+		for _, cl := range pl.Claims {
+			if err := g.checkDoubleRoute(u, pl, cl); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return g, nil
+}
+
+// Returns an error if the player's claim breaks the double-route rules: no
+// player may claim both routes of a double route, and in a game with too few
+// players, only one of them may be claimed at all.
+func (g *GameState) checkDoubleRoute(u *Univ, pl *PlayerState, cl *Claim) error {
+	for _, other := range g.Players {
+		for _, ocl := range other.Claims {
+			if ocl == cl || newLink(ocl.City1, ocl.City2) != newLink(cl.City1, cl.City2) {
+				continue
+			}
+			if other == pl {
+				return fmt.Errorf("player %q claims both routes between %q and %q", pl.Name, cl.City1.Name,
+					cl.City2.Name)
+			}
+			if len(g.Players) < u.doubleRouteMinPlayers {
+				return fmt.Errorf("both routes between %q and %q are claimed, but only one may be in a game with %d players",
+					cl.City1.Name, cl.City2.Name, len(g.Players))
+			}
+		}
+	}
+	return nil
+}
+
+// A player's final score, broken down.
+type PlayerScore struct {
+	Name  string
+	Total int
+
+	Routes      []RouteLengthCount // by length, shortest first
+	RoutePoints int
+
+	Tickets      []TicketResult // in the order held
+	TicketPoints int
+
+	LongestRoute      int // the length of the player's longest continuous path
+	LongestRouteBonus int
+
+	StationsUsed  int
+	StationPoints int
+	Borrowed      []*Claim // opponents' routes that the player's stations use
+}
+
+// How many routes of a length a player claimed, and their points.
+type RouteLengthCount struct {
+	Length int `json:"length"`
+	Count  int `json:"count"`
+	Points int `json:"points"`
+}
+
+type TicketResult struct {
+	Dest      *Dest
+	Completed bool
+}
+
+// Scores a finished game. Each player scores points for their routes by length,
+// gains the value of each destination ticket their routes complete and loses
+// the value of each they fail, and, on a board with stations, scores points for
+// each unused station. The player or players with the longest continuous path
+// get the Longest Route bonus.
+//
+// Each station lets its player use one route of an opponent into the
+// station's city to complete tickets; the stations use whichever routes score
+// the most. Borrowed routes don't count toward the longest path.
+func ScoreGame(g *GameState) []*PlayerScore {
+	var scores []*PlayerScore
+	longest := 0
+	for _, pl := range g.Players {
+		sc := &PlayerScore{Name: pl.Name}
+		counts := make(map[int]int)
+		for _, cl := range pl.Claims {
+			counts[cl.Route.Dist]++
+		}
+		for length, n := range counts {
+			rlc := RouteLengthCount{Length: length, Count: n, Points: n * RoutePoints(length)}
+			sc.Routes = append(sc.Routes, rlc)
+			sc.RoutePoints += rlc.Points
+		}
+		sort.Slice(sc.Routes, func(i, j int) bool { return sc.Routes[i].Length < sc.Routes[j].Length })
+
+		g.scoreTickets(pl, sc)
+
+		if p := LongestTrail(pl.Claims); p != nil {
+			sc.LongestRoute = p.Dist
+		}
+		if sc.LongestRoute > longest {
+			longest = sc.LongestRoute
+		}
+
+		sc.StationsUsed = len(pl.Stations)
+		sc.StationPoints = StationPoints * (g.StationsPerPlayer - sc.StationsUsed)
+		scores = append(scores, sc)
+	}
+	for _, sc := range scores {
+		if longest > 0 && sc.LongestRoute == longest {
+			sc.LongestRouteBonus = LongestRouteBonus
+		}
+		sc.Total = sc.RoutePoints + sc.TicketPoints + sc.LongestRouteBonus + sc.StationPoints
+	}
+	return scores
+}
+
+// Finds which of the player's tickets are completed, choosing the routes that
+// the player's stations borrow so as to score the most ticket points.
+func (g *GameState) scoreTickets(pl *PlayerState, sc *PlayerScore) {
+	owned := make(map[link]bool)
+	for _, cl := range pl.Claims {
+		owned[newLink(cl.City1, cl.City2)] = true
+	}
+
+	// the opponents' routes into each station's city:
+	options := make([][]*Claim, len(pl.Stations))
+	for i, c := range pl.Stations {
+		for _, other := range g.Players {
+			if other == pl {
+				continue
+			}
+			for _, cl := range other.Claims {
+				if cl.City1 == c || cl.City2 == c {
+					options[i] = append(options[i], cl)
+				}
+			}
+		}
+	}
+
+	// Try every choice of borrowed routes, with a station borrowing nothing if
+	// there's nothing to borrow.
+	found, best := false, 0
+	borrowed := make([]*Claim, len(pl.Stations))
+	var try func(i int)
+	try = func(i int) {
+		if i < len(options) {
+			if len(options[i]) == 0 {
+				borrowed[i] = nil
+				try(i + 1)
+			}
+			for _, cl := range options[i] {
+				borrowed[i] = cl
+				try(i + 1)
+			}
+			return
+		}
+		usable := make(map[link]bool)
+		for l := range owned {
+			usable[l] = true
+		}
+		for _, cl := range borrowed {
+			if cl != nil {
+				usable[newLink(cl.City1, cl.City2)] = true
+			}
+		}
+		var results []TicketResult
+		points := 0
+		for _, d := range pl.Tickets {
+			done := connects(d.City1, d.City2, func(a, b *City) bool { return usable[newLink(a, b)] })
+			results = append(results, TicketResult{d, done})
+			if done {
+				points += d.Value
+			} else {
+				points -= d.Value
+			}
+		}
+		if !found || points > best {
+			found, best = true, points
+			sc.Tickets = results
+			sc.TicketPoints = points
+			sc.Borrowed = nil
+			for _, cl := range borrowed {
+				if cl != nil {
+					sc.Borrowed = append(sc.Borrowed, cl)
+				}
+			}
+		}
+	}
+	try(0)
+}
+
+// Returns whether two cities are connected by a chain of adjacent cities, each
+// pair of which the linked function accepts.
+func connects(c1, c2 *City, linked func(a, b *City) bool) bool {
+	seen := map[*City]bool{c1: true}
+	stack := []*City{c1}
+	for len(stack) > 0 {
+		c := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if c == c2 {
+			return true
+		}
+		for _, adj := range c.adj {
+			if !seen[adj] && linked(c, adj) {
+				seen[adj] = true
+				stack = append(stack, adj)
+			}
+		}
+	}
+	return false
+}
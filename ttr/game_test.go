@@ -0,0 +1,124 @@
+package ttr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestScoreGame(t *testing.T) {
+	u := newUnivNoPaths(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 2 red, 2 blue
+		bravo - charlie: 3 wild
+		charlie - delta: 1 wild
+		delta - echo: 4 green
+		alpha - echo: 6 wild
+	`))
+	u.stations = 3
+
+	// Ann's station in delta borrows Bob's route from charlie to complete
+	// alpha–delta. Both players' longest paths have length 5.
+	g, err := loadGameState(u, strings.NewReader(`
+		[Ann]
+		alpha - bravo: 2 red
+		bravo - charlie: 3 wild
+		ticket alpha - charlie: 5
+		ticket alpha - delta: 7
+		station delta
+
+		[Bob]
+		charlie - delta: 1 wild
+		delta - echo: 4 green
+		ticket charlie - echo: 6
+		ticket alpha - echo: 9
+	`))
+	if err != nil {
+		t.Fatalf("got error loading game state: %s", err)
+	}
+	scores := ScoreGame(g)
+	if len(scores) != 2 {
+		t.Fatalf("expected 2 scores but got %d", len(scores))
+	}
+
+	ann, bob := scores[0], scores[1]
+	if ann.RoutePoints != 6 || len(ann.Routes) != 2 || ann.Routes[1] != (RouteLengthCount{3, 1, 4}) {
+		t.Errorf("got unexpected route points for Ann: %d, %v", ann.RoutePoints, ann.Routes)
+	}
+	if ann.TicketPoints != 12 || !ann.Tickets[1].Completed || len(ann.Borrowed) != 1 ||
+		ann.Borrowed[0].Route.Color != "wild" {
+		t.Errorf("got unexpected tickets for Ann: %d, %v, borrowed %v", ann.TicketPoints, ann.Tickets, ann.Borrowed)
+	}
+	if ann.LongestRoute != 5 || ann.LongestRouteBonus != 10 || ann.StationPoints != 8 || ann.Total != 36 {
+		t.Errorf("got unexpected score for Ann: %+v", *ann)
+	}
+	if bob.TicketPoints != -3 || bob.Tickets[1].Completed || bob.LongestRouteBonus != 10 ||
+		bob.StationPoints != 12 || bob.Total != 8-3+10+12 {
+		t.Errorf("got unexpected score for Bob: %+v", *bob)
+	}
+}
+
+func TestScoreGameWithoutStations(t *testing.T) {
+	m := mustLoadMap("usa")
+	u, err := NewUniv(m)
+	if err != nil {
+		t.Fatalf("got error creating universe: %s", err)
+	}
+	g, err := loadGameState(u, strings.NewReader(`
+		[Ann]
+		Denver - Omaha: 4 pink
+		ticket Denver - Omaha: 4
+		[Bob]
+		Helena - Omaha: 5 red
+	`))
+	if err != nil {
+		t.Fatalf("got error loading game state: %s", err)
+	}
+	for _, sc := range ScoreGame(g) {
+		if sc.StationPoints != 0 {
+			t.Errorf("expected no station points for %s but got %d", sc.Name, sc.StationPoints)
+		}
+	}
+	if ann := ScoreGame(g)[0]; ann.Total != 7+4 {
+		t.Errorf("expected 11 points for Ann but got %d", ann.Total)
+	}
+
+	if _, err := loadGameState(u, strings.NewReader("[Ann]\nstation Omaha\n")); err == nil {
+		t.Errorf("expected error for a station on a board without stations but got none")
+	}
+}
+
+func TestLoadGameStateErrors(t *testing.T) {
+	u := newUnivNoPaths(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 2 red, 2 blue
+		bravo - charlie: 3 wild
+		charlie - delta: 1 wild
+	`))
+	u.stations = 3
+
+	for _, s := range []string{
+		"alpha - bravo: 2 red",
+		"[Ann]\nalpha - bravo: 2 red\n[Ann]\n",
+		"[Ann]\nalpha - bravo: 2 red\nalpha - bravo: 2 blue",
+		"[Ann]\nalpha - bravo: 2 red\n[Bob]\nalpha - bravo: 2 blue",
+		"[Ann]\nbravo - charlie: 3 wild\n[Bob]\nbravo - charlie: 3 wild",
+		"[Ann]\nticket alpha - zulu: 3",
+		"[Ann]\nstation alpha\n[Bob]\nstation alpha",
+		"[Ann]\nstation zulu",
+		"[Ann]\nstation alpha\nstation bravo\nstation charlie\nstation delta",
+	} {
+		if _, err := loadGameState(u, strings.NewReader(s)); err == nil {
+			t.Errorf("expected error for %q but got none", s)
+		}
+	}
+
+	// Both routes of a double route may be claimed in a four-player game.
+	s := "[Ann]\nalpha - bravo: 2 red\n[Bob]\nalpha - bravo: 2 blue\n[Cat]\n[Dan]\n"
+	if _, err := loadGameState(u, strings.NewReader(s)); err != nil {
+		t.Errorf("got error for %q: %s", s, err)
+	}
+
+	line := "  ticket alpha - bravo: x"
+	_, err := loadGameState(u, strings.NewReader("[Ann]\n"+line))
+	if pe, ok := err.(*ParseError); !ok || pe.Line != 2 || pe.Column != strings.Index(line, "x")+1 {
+		t.Errorf("expected parse error at line 2, column %d but got %v", strings.Index(line, "x")+1, err)
+	}
+}
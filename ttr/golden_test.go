@@ -0,0 +1,75 @@
+package ttr
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// Compares output with the golden file of the given name in testdata, or, with
+// the -update flag, rewrites the golden file.
+func checkGolden(t *testing.T, name string, got []byte) {
+	filename := filepath.Join("testdata", name)
+	if *update {
+		if err := ioutil.WriteFile(filename, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	exp, err := ioutil.ReadFile(filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, exp) {
+		t.Errorf("output differs from %s (rerun with -update if the change is intended):\n%s", filename, got)
+	}
+}
+
+func loadGoldenUniv(t *testing.T) *Univ {
+	m, err := LoadBundledMap("usa")
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err := NewUniv(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestGoldenDests(t *testing.T) {
+	for _, mode := range []string{"equal", "weighted", "constrained"} {
+		opts := GenerateOptions{N: 30, Seed: 42, Mode: mode}
+		opts.Constraints.MinLong = 5
+
+		// a new universe each time, so that nothing depends on the order in which
+		// its maps were filled
+		dests, err := GenerateDestinations(loadGoldenUniv(t), opts)
+		if err != nil {
+			t.Fatalf("%s mode: got error making destinations: %s", mode, err)
+		}
+		var buf bytes.Buffer
+		if err := WriteDests(&buf, dests); err != nil {
+			t.Fatal(err)
+		}
+		checkGolden(t, "usa-"+mode+"-42.dat", buf.Bytes())
+	}
+}
+
+func TestGoldenPaths(t *testing.T) {
+	u := loadGoldenUniv(t)
+	var buf bytes.Buffer
+	for _, c1 := range u.Cities() {
+		for _, c2 := range u.Cities() {
+			if c1 != c2 {
+				fmt.Fprintf(&buf, "%s\n%s\n", c1.fewestHops[c2], c1.shortestDist[c2])
+			}
+		}
+	}
+	checkGolden(t, "usa-paths.txt", buf.Bytes())
+}
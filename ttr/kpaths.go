@@ -0,0 +1,141 @@
+package ttr
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+)
+
+// Returns up to k of the shortest paths between two cities, best first, with
+// no path visiting any city twice. Paths are ordered as by ShortestPath. Where
+// two cities are connected by more than one route, a path uses the shortest,
+// so no two of the paths visit the same cities in the same order. It's an error
+// if k is less than 1.
+//
+// This is Yen's algorithm: each path after the first is found by deviating
+// from an earlier path at one of its cities.
+func (u *Univ) KShortestPaths(from, to string, k int) ([]*Path, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("invalid number of paths %d", k)
+	}
+	src := u.cityByName[from]
+	if src == nil {
+		return nil, fmt.Errorf("city %q doesn't exist", from)
+	}
+	dst := u.cityByName[to]
+	if dst == nil {
+		return nil, fmt.Errorf("city %q doesn't exist", to)
+	}
+	first := findSpurPath(src, dst, nil, nil)
+	if first == nil {
+		return nil, fmt.Errorf("no path from %q to %q", from, to)
+	}
+
+	found := []*Path{first}
+	var candidates []*Path
+	for len(found) < k {
+		prev := found[len(found)-1]
+		for i := 0; i < len(prev.Routes); i++ {
+			root := prev.Cities[:i+1]
+			spur := root[i]
+
+			// Don't let the spur path repeat the next hop of any path already found
+			// with the same root, nor revisit the root's cities.
+			bannedLinks := make(map[link]bool)
+			for _, p := range found {
+				if len(p.Cities) > i+1 && sameCities(p.Cities[:i+1], root) {
+					bannedLinks[newLink(p.Cities[i], p.Cities[i+1])] = true
+				}
+			}
+			bannedCities := make(map[*City]bool)
+			for _, c := range root[:i] {
+				bannedCities[c] = true
+			}
+
+			spurPath := findSpurPath(spur, dst, bannedCities, bannedLinks)
+			if spurPath == nil {
+				continue
+			}
+			p := newPath(src)
+			for j, r := range prev.Routes[:i] {
+				p.appendHop(prev.Cities[j+1], r)
+			}
+			for j, r := range spurPath.Routes {
+				p.appendHop(spurPath.Cities[j+1], r)
+			}
+			if !containsPath(candidates, p) && !containsPath(found, p) {
+				candidates = append(candidates, p)
+			}
+		}
+		if len(candidates) == 0 {
+			break
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return compShortestDist(candidates[i], candidates[j]) > 0
+		})
+		found = append(found, candidates[0])
+		candidates = candidates[1:]
+	}
+	return found, nil
+}
+
+// Returns the shortest path from one city to another that avoids the given
+// cities and links, or nil if there's no such path.
+func findSpurPath(src, dst *City, bannedCities map[*City]bool, bannedLinks map[link]bool) *Path {
+	visited := make(map[*City]bool)
+	pending := &pathHeap{comp: compShortestDist}
+	heap.Push(pending, newPath(src))
+	for pending.Len() > 0 {
+		p := heap.Pop(pending).(*Path)
+		curCity := p.Cities[len(p.Cities)-1]
+		if visited[curCity] {
+			continue
+		}
+		if curCity == dst {
+			return p
+		}
+		visited[curCity] = true
+		for _, adjCity := range curCity.Neighbors() {
+			if visited[adjCity] || bannedCities[adjCity] || bannedLinks[newLink(curCity, adjCity)] {
+				continue
+			}
+			next := copyPath(p)
+			next.appendHop(adjCity, bestRoute(curCity.routes[adjCity]))
+			heap.Push(pending, next)
+		}
+	}
+	return nil
+}
+
+// Returns the shortest of the routes between two adjacent cities, breaking ties
+// by fewest hazards.
+func bestRoute(routes []*Route) *Route {
+	best := routes[0]
+	for _, r := range routes[1:] {
+		if compInts(r.Dist, best.Dist, r.Hazards(), best.Hazards()) > 0 {
+			best = r
+		}
+	}
+	return best
+}
+
+func sameCities(a, b []*City) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func containsPath(paths []*Path, p *Path) bool {
+	for _, x := range paths {
+		if sameCities(x.Cities, p.Cities) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package ttr
+
+import (
+	"testing"
+)
+
+func TestKShortestPaths(t *testing.T) {
+
+	//   alpha --1-- bravo --1-- delta
+	//     |           |           |
+	//     3           1           1
+	//     |           |           |
+	//   charlie --1-- echo --5-- foxtrot
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+alpha - bravo: 1 wild
+bravo - delta: 1 wild, 1 red
+alpha - charlie: 3 wild
+bravo - echo: 1 wild
+delta - foxtrot: 1 wild
+charlie - echo: 1 wild
+echo - foxtrot: 5 wild
+`))
+
+	paths, err := u.KShortestPaths("alpha", "foxtrot", 10)
+	if err != nil {
+		t.Fatalf("got error: %s", err)
+	}
+	exp := []string{
+		`"alpha"–1,wild–"bravo"–1,wild–"delta"–1,wild–"foxtrot"`,
+		`"alpha"–1,wild–"bravo"–1,wild–"echo"–5,wild–"foxtrot"`,
+		`"alpha"–3,wild–"charlie"–1,wild–"echo"–1,wild–"bravo"–1,wild–"delta"–1,wild–"foxtrot"`,
+		`"alpha"–3,wild–"charlie"–1,wild–"echo"–5,wild–"foxtrot"`,
+	}
+	if len(paths) != len(exp) {
+		t.Fatalf("expected %d paths but got %d: %v", len(exp), len(paths), paths)
+	}
+	for i := range exp {
+		if got := paths[i].String(); got != exp[i] {
+			t.Errorf("path #%d: expected %s but got %s", i+1, exp[i], got)
+		}
+	}
+
+	// fewer paths than asked for:
+	if paths, _ = u.KShortestPaths("alpha", "foxtrot", 2); len(paths) != 2 {
+		t.Errorf("expected 2 paths but got %d", len(paths))
+	}
+
+	// the first path is the shortest path:
+	p, _ := u.ShortestPath("alpha", "foxtrot")
+	if paths, _ = u.KShortestPaths("alpha", "foxtrot", 1); !paths[0].Equals(p) {
+		t.Errorf("expected %s but got %s", p, paths[0])
+	}
+
+	if _, err = u.KShortestPaths("alpha", "golf", 1); err == nil {
+		t.Errorf("expected error for unknown city but got none")
+	}
+	for _, k := range []int{0, -1} {
+		if _, err = u.KShortestPaths("alpha", "foxtrot", k); err == nil {
+			t.Errorf("expected error for k = %d but got none", k)
+		}
+	}
+}
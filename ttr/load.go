@@ -0,0 +1,450 @@
+package ttr
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// A ParseError is a syntax error or invalid value in a data file. Line and
+// Column are one-based, with Column counting bytes, and either may be zero if
+// unknown. File is empty if the input didn't come from a named file.
+type ParseError struct {
+	File   string
+	Line   int
+	Column int
+	Msg    string
+}
+
+func newParseError(line, col int, format string, a ...interface{}) *ParseError {
+	return &ParseError{Line: line, Column: col, Msg: fmt.Sprintf(format, a...)}
+}
+
+// Formats the error as "file:line:column: message", like a compiler, or, if
+// there's no file name, as "message at line L, column C".
+func (e *ParseError) Error() string {
+	if e.File != "" {
+		s := e.File
+		if e.Line > 0 {
+			s += fmt.Sprintf(":%d", e.Line)
+			if e.Column > 0 {
+				s += fmt.Sprintf(":%d", e.Column)
+			}
+		}
+		return s + ": " + e.Msg
+	}
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("%s at line %d, column %d", e.Msg, e.Line, e.Column)
+	case e.Line > 0:
+		return fmt.Sprintf("%s at line %d", e.Msg, e.Line)
+	}
+	return e.Msg
+}
+
+// Attributes an error from loading a file to that file. A parse error gets the
+// file name, and any other error is prefixed with it. An empty file name leaves
+// the error as it is.
+func withFile(err error, filename string) error {
+	if err == nil || filename == "" {
+		return err
+	}
+	if pe, ok := err.(*ParseError); ok {
+		pe.File = filename
+		return pe
+	}
+	return fmt.Errorf("%s: %s", filename, err)
+}
+
+// Opens a file and passes it to a load function, attributing any error to the
+// file.
+func loadFile(filename string, load func(r io.Reader) error) error {
+	file, err := os.Open(filename)
+	if err != nil {
+		return err // already names the file
+	}
+	err = withFile(load(file), filename)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// Calls f for each line of input that isn't blank, with the line's trailing
+// whitespace removed. Leading whitespace is kept so that columns counted from
+// the start of the line are correct.
+func forEachLine(r io.Reader, f func(line string, lineNo int) error) error {
+	bufRdr := bufio.NewReader(r)
+	var lineNo int
+
+	for {
+		lineNo++
+		line, err := bufRdr.ReadString('\n')
+		if len(line) == 0 && err == io.EOF {
+			return nil
+		} else if err != nil && err != io.EOF {
+			return fmt.Errorf("input error at line %d: %s", lineNo, err)
+		}
+
+		line = strings.TrimRight(line, " \t\r\n")
+		if len(strings.TrimSpace(line)) == 0 {
+			continue // ignore empty lines
+		}
+		if err := f(line, lineNo); err != nil {
+			return err
+		}
+	}
+}
+
+// Returns a function that gives the one-based column at which a suffix of the
+// line begins.
+func columnOf(line string) func(suffix string) int {
+	return func(suffix string) int {
+		return len(line) - len(suffix) + 1
+	}
+}
+
+func trimLeft(s string) string {
+	return strings.TrimLeft(s, " \t")
+}
+
+type destEnt struct {
+	name1 string
+	name2 string
+	value int
+	line  int // in the file the entry was loaded from, or zero if unknown
+}
+
+func loadDestEntries(r io.Reader) (ents []destEnt, err error) {
+	err = forEachLine(r, func(line string, lineNo int) error {
+		ent, err := parseDestLine(line, lineNo)
+		ents = append(ents, ent)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+// Parses a line giving a destination, e.g., "Alpha - Bravo: 5".
+func parseDestLine(line string, lineNo int) (ent destEnt, err error) {
+	col := columnOf(line)
+	ent.line = lineNo
+
+	if ent.name1, ent.name2, line, err = parseCityPair(line, lineNo, col); err != nil {
+		return
+	}
+
+	// value:
+	line = trimLeft(line)
+	var value int64
+	if value, err = strconv.ParseInt(line, 0, 0); err != nil {
+		return ent, newParseError(lineNo, col(line), "invalid destination value %q", line)
+	}
+	ent.value = int(value)
+
+	return
+}
+
+// Parses the "Alpha - Bravo:" that begins a destination or route line. Returns
+// the rest of the line, after the colon.
+func parseCityPair(line string, lineNo int, col func(string) int) (name1, name2, rest string, err error) {
+
+	// first city name:
+	index := strings.Index(line, "-")
+	if index == -1 {
+		return "", "", "", newParseError(lineNo, col(trimLeft(line)), "missing '-'")
+	}
+	if name1 = strings.TrimSpace(line[:index]); len(name1) == 0 {
+		return "", "", "", newParseError(lineNo, col(trimLeft(line)), "missing city name")
+	}
+	line = line[index+1:]
+
+	// second city name:
+	if index = strings.Index(line, ":"); index == -1 {
+		return "", "", "", newParseError(lineNo, col(trimLeft(line)), "missing ':'")
+	}
+	if name2 = strings.TrimSpace(line[:index]); len(name2) == 0 {
+		return "", "", "", newParseError(lineNo, col(trimLeft(line)), "missing city name")
+	}
+
+	return name1, name2, line[index+1:], nil
+}
+
+func loadDestEntriesFromFile(filename string) (ents []destEnt, err error) {
+	err = loadFile(filename, func(r io.Reader) (err error) {
+		ents, err = loadDestEntries(r)
+		return
+	})
+	return
+}
+
+// Writes destination entries in the same format that loadDestEntries reads.
+func writeDestEntries(w io.Writer, ents []destEnt) error {
+	for _, ent := range ents {
+		if _, err := fmt.Fprintf(w, "%s - %s: %d\n", ent.name1, ent.name2, ent.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type regionEnt struct {
+	name      string
+	cityNames []string
+}
+
+func loadRegionEntries(r io.Reader) (ents []regionEnt, err error) {
+	err = forEachLine(r, func(line string, lineNo int) error {
+		col := columnOf(line)
+
+		// region name:
+		index := strings.Index(line, ":")
+		if index == -1 {
+			return newParseError(lineNo, col(trimLeft(line)), "missing ':'")
+		}
+		var ent regionEnt
+		ent.name = strings.TrimSpace(line[:index])
+		line = line[index+1:]
+
+		// city names:
+		for {
+			if index = strings.Index(line, ","); index == -1 {
+				index = len(line)
+			}
+			name := strings.TrimSpace(line[:index])
+			if len(name) == 0 {
+				return newParseError(lineNo, col(line), "missing city name")
+			}
+			ent.cityNames = append(ent.cityNames, name)
+			if index == len(line) {
+				break
+			}
+			line = line[index+1:]
+		}
+		ents = append(ents, ent)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return
+}
+
+func loadRegionEntriesFromFile(filename string) (ents []regionEnt, err error) {
+	err = loadFile(filename, func(r io.Reader) (err error) {
+		ents, err = loadRegionEntries(r)
+		return
+	})
+	return
+}
+
+type routeEnt struct {
+	name1   string
+	name2   string
+	dist    int
+	color   string
+	tunnel  bool
+	ferries int
+	line    int // in the file the entry was loaded from, or zero if unknown
+}
+
+type coordEnt struct {
+	name string
+	x    float64
+	y    float64
+}
+
+// Loads a map file, which comprises a list of routes followed by an optional
+// "[coordinates]" section giving each city's position. The list of routes may
+// be preceded by an optional "[routes]" header.
+//
+// Coordinates use any units, with x increasing to the right and y increasing
+// downward, as on the game board.
+func loadMapEntries(r io.Reader) (routeEnts []routeEnt, coordEnts []coordEnt, err error) {
+	section := "routes"
+	err = forEachLine(r, func(line string, lineNo int) error {
+
+		// section header:
+		if header := trimLeft(line); strings.HasPrefix(header, "[") {
+			if !strings.HasSuffix(header, "]") {
+				return newParseError(lineNo, len(line)+1, "missing ']'")
+			}
+			section = strings.TrimSpace(header[1 : len(header)-1])
+			if section != "routes" && section != "coordinates" {
+				return newParseError(lineNo, columnOf(line)(header), "unknown section %q", section)
+			}
+			return nil
+		}
+
+		switch section {
+		case "routes":
+			ents, err := parseRouteLine(line, lineNo)
+			if err != nil {
+				return err
+			}
+			routeEnts = append(routeEnts, ents...)
+		case "coordinates":
+			ent, err := parseCoordLine(line, lineNo)
+			if err != nil {
+				return err
+			}
+			coordEnts = append(coordEnts, ent)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return
+}
+
+func loadRouteEntries(r io.Reader) (ents []routeEnt, err error) {
+	ents, _, err = loadMapEntries(r)
+	return
+}
+
+// Parses a line of one or more routes between two cities, e.g.,
+// "Alpha - Bravo: 2 red, 2 wild tunnel, 1 wild ferry=1". Each route is a
+// distance and color, optionally followed by "tunnel" and "ferry=N", where N is
+// the number of locomotives required, at most the distance ("ferry" alone
+// means one).
+func parseRouteLine(line string, lineNo int) (ents []routeEnt, err error) {
+	col := columnOf(line)
+
+	var city1, city2 string
+	if city1, city2, line, err = parseCityPair(line, lineNo, col); err != nil {
+		return nil, err
+	}
+
+	// route descriptions, separated by commas:
+	for {
+		if line = trimLeft(line); len(line) == 0 {
+			break
+		}
+		var ent routeEnt
+		index := strings.IndexAny(line, " \t")
+		if index == -1 {
+			return nil, newParseError(lineNo, col(line)+len(line), "missing route color")
+		}
+		distText := line[:index]
+		var dist int64
+		if dist, err = strconv.ParseInt(distText, 0, 0); err != nil {
+			return nil, newParseError(lineNo, col(line), "invalid route distance %q", distText)
+		}
+		ent.dist = int(dist)
+		line = trimLeft(line[index:])
+		if index = strings.Index(line, ","); index == -1 {
+			index = len(line)
+		}
+
+		// color and attributes:
+		fields, offsets := splitFields(line[:index])
+		if len(fields) == 0 {
+			return nil, newParseError(lineNo, col(line), "missing route color")
+		}
+		ent.color = fields[0]
+		ferryCol := 0
+		for i, attr := range fields[1:] {
+			attrCol := col(line) + offsets[i+1]
+			switch {
+			case attr == "tunnel":
+				ent.tunnel = true
+			case attr == "ferry":
+				ent.ferries = 1
+				ferryCol = attrCol
+			case strings.HasPrefix(attr, "ferry="):
+				var ferries int64
+				if ferries, err = strconv.ParseInt(attr[len("ferry="):], 0, 0); err != nil || ferries < 1 {
+					return nil, newParseError(lineNo, attrCol, "invalid ferry count %q", attr)
+				}
+				ent.ferries = int(ferries)
+				ferryCol = attrCol
+			default:
+				return nil, newParseError(lineNo, attrCol, "unknown route attribute %q", attr)
+			}
+		}
+		if ent.ferries > ent.dist {
+			return nil, newParseError(lineNo, ferryCol, "ferry count %d exceeds route distance %d", ent.ferries, ent.dist)
+		}
+		ent.name1 = city1
+		ent.name2 = city2
+		ent.line = lineNo
+		ents = append(ents, ent)
+		if index == len(line) {
+			break
+		}
+		line = line[index+1:] // chop ','
+	}
+
+	return ents, nil
+}
+
+// Splits a string into whitespace-separated fields, like strings.Fields, also
+// returning the byte offset of each field.
+func splitFields(s string) (fields []string, offsets []int) {
+	start := -1
+	for i := 0; i <= len(s); i++ {
+		blank := i == len(s) || s[i] == ' ' || s[i] == '\t'
+		if blank && start != -1 {
+			fields = append(fields, s[start:i])
+			offsets = append(offsets, start)
+			start = -1
+		} else if !blank && start == -1 {
+			start = i
+		}
+	}
+	return
+}
+
+func parseCoordLine(line string, lineNo int) (ent coordEnt, err error) {
+	col := columnOf(line)
+
+	// city name:
+	index := strings.Index(line, ":")
+	if index == -1 {
+		return ent, newParseError(lineNo, col(trimLeft(line)), "missing ':'")
+	}
+	ent.name = strings.TrimSpace(line[:index])
+	line = line[index+1:]
+
+	// x and y:
+	if index = strings.Index(line, ","); index == -1 {
+		return ent, newParseError(lineNo, col(trimLeft(line)), "missing ','")
+	}
+	xText := strings.TrimSpace(line[:index])
+	if ent.x, err = strconv.ParseFloat(xText, 64); err != nil {
+		return ent, newParseError(lineNo, col(trimLeft(line)), "invalid x coordinate %q", xText)
+	}
+	line = trimLeft(line[index+1:])
+	if ent.y, err = strconv.ParseFloat(line, 64); err != nil {
+		return ent, newParseError(lineNo, col(line), "invalid y coordinate %q", line)
+	}
+
+	return ent, nil
+}
+
+// Loads a map from a file. A file whose name ends with ".json" is a JSON map
+// file. Any other file is a route file, possibly with coordinates, and the map
+// is named after the file.
+func loadMapFromFile(filename string) (m *Map, err error) {
+	err = loadFile(filename, func(r io.Reader) (err error) {
+		if strings.HasSuffix(strings.ToLower(filename), ".json") {
+			m, err = loadJSONMap(r)
+			return
+		}
+		m = &Map{Name: strings.TrimSuffix(filepath.Base(filename), filepath.Ext(filename))}
+		m.routeEnts, m.coordEnts, err = loadMapEntries(r)
+		return
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.file = filename
+	return
+}
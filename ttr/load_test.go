@@ -0,0 +1,381 @@
+package ttr
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadDestEntriesParser(t *testing.T) {
+	type tc struct {
+		inText  string
+		expEnts []destEnt
+	}
+
+	tcs := []tc{
+		// no whitespace:
+		{"alpha-bravo:2\nalpha-charlie:2\n", []destEnt{
+			destEnt{"alpha", "bravo", 2, 1},
+			destEnt{"alpha", "charlie", 2, 2},
+		}},
+		// "normal" whitespace:
+		{"alpha - bravo: 2\nalpha - charlie: 2\n", []destEnt{
+			destEnt{"alpha", "bravo", 2, 1},
+			destEnt{"alpha", "charlie", 2, 2},
+		}},
+		// whitespace before first city
+		{" \t alpha - bravo: 2\n \t alpha - charlie: 2\n", []destEnt{
+			destEnt{"alpha", "bravo", 2, 1},
+			destEnt{"alpha", "charlie", 2, 2},
+		}},
+
+		// tabs instead of spaces:
+		{"alpha\t-\tbravo:\t2\nalpha\t-\tcharlie:\t2\n", []destEnt{
+			destEnt{"alpha", "bravo", 2, 1},
+			destEnt{"alpha", "charlie", 2, 2},
+		}},
+		// empty lines:
+		{"alpha - bravo: 2\n\n\nalpha - charlie: 2\n\n\n", []destEnt{
+			destEnt{"alpha", "bravo", 2, 1},
+			destEnt{"alpha", "charlie", 2, 4},
+		}},
+		// no end-of-line on last line:
+		{"alpha - bravo: 2\nalpha - charlie: 2", []destEnt{
+			destEnt{"alpha", "bravo", 2, 1},
+			destEnt{"alpha", "charlie", 2, 2},
+		}},
+		// empty input:
+		{"", []destEnt{}},
+	}
+
+	// run test cases:
+	for _, tc := range tcs {
+		if dests, err := loadDestEntries(strings.NewReader(tc.inText)); err != nil {
+			t.Errorf("got error loading %q: %s", tc.inText, err)
+		} else if len(dests) != len(tc.expEnts) {
+			t.Errorf("expected %v destination(s) but got %v (%q, %v)", len(tc.expEnts), len(dests), tc.inText, dests)
+		} else {
+			for i, exp := range tc.expEnts {
+				got := dests[i]
+				if exp != got {
+					t.Errorf("expected destination %v to be %v but got %v", i, exp, got)
+				}
+			}
+		}
+	}
+}
+
+func TestWriteDestEntries(t *testing.T) {
+	ents := []destEnt{
+		destEnt{"alpha", "bravo", 2, 1},
+		destEnt{"Sault St. Marie", "New York", 12, 2},
+	}
+	var buf bytes.Buffer
+	if err := writeDestEntries(&buf, ents); err != nil {
+		t.Fatalf("got error writing destinations: %s", err)
+	}
+	got, err := loadDestEntries(&buf)
+	if err != nil {
+		t.Fatalf("got error loading destinations: %s", err)
+	}
+	if len(got) != len(ents) {
+		t.Fatalf("expected %d destination(s) but got %d (%v)", len(ents), len(got), got)
+	}
+	for i, exp := range ents {
+		if got[i] != exp {
+			t.Errorf("expected destination %v to be %v but got %v", i, exp, got[i])
+		}
+	}
+}
+
+func TestLoadDestEntriesReal(t *testing.T) {
+	mustLoadDestEntriesFromFile("../destinations.dat")
+}
+
+func TestLoadRegionEntriesParser(t *testing.T) {
+	ents, err := loadRegionEntries(strings.NewReader("east: alpha, bravo\n\n \twest:charlie\n"))
+	if err != nil {
+		t.Fatalf("got error loading regions: %s", err)
+	}
+	if len(ents) != 2 {
+		t.Fatalf("expected 2 regions but got %d (%v)", len(ents), ents)
+	}
+	if ents[0].name != "east" || len(ents[0].cityNames) != 2 || ents[0].cityNames[0] != "alpha" ||
+		ents[0].cityNames[1] != "bravo" {
+		t.Errorf("got unexpected region %v", ents[0])
+	}
+	if ents[1].name != "west" || len(ents[1].cityNames) != 1 || ents[1].cityNames[0] != "charlie" {
+		t.Errorf("got unexpected region %v", ents[1])
+	}
+
+	for _, inText := range []string{"east alpha\n", "east: alpha,,bravo\n"} {
+		if _, err := loadRegionEntries(strings.NewReader(inText)); err == nil {
+			t.Errorf("expected error loading %q but got none", inText)
+		}
+	}
+}
+
+func TestLoadRegionEntriesReal(t *testing.T) {
+	mustLoadRegionEntriesFromFile("../regions.dat")
+}
+
+func TestLoadRouteEntriesParser(t *testing.T) {
+	type tc struct {
+		inText  string
+		expEnts []routeEnt
+	}
+
+	tcs := []tc{
+		// no whitespace:
+		{"alpha-bravo:2 blue,2 orange\nalpha-charlie:2 wild\n", []routeEnt{
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "blue", line: 1},
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "orange", line: 1},
+			routeEnt{name1: "alpha", name2: "charlie", dist: 2, color: "wild", line: 2},
+		}},
+		// "normal" whitespace:
+		{"alpha - bravo: 2 blue, 2 orange\nalpha - charlie: 2 wild\n", []routeEnt{
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "blue", line: 1},
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "orange", line: 1},
+			routeEnt{name1: "alpha", name2: "charlie", dist: 2, color: "wild", line: 2},
+		}},
+		// whitespace before first city
+		{" \t alpha - bravo: 2 blue, 2 orange\n \t alpha - charlie: 2 wild\n", []routeEnt{
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "blue", line: 1},
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "orange", line: 1},
+			routeEnt{name1: "alpha", name2: "charlie", dist: 2, color: "wild", line: 2},
+		}},
+		// tabs instead of spaces:
+		{"alpha\t-\tbravo:\t2\tblue,\t2\torange\nalpha\t-\tcharlie:\t2\twild\n", []routeEnt{
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "blue", line: 1},
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "orange", line: 1},
+			routeEnt{name1: "alpha", name2: "charlie", dist: 2, color: "wild", line: 2},
+		}},
+		// empty lines:
+		{"\n\nalpha - bravo: 2 blue, 2 orange\n\n\nalpha - charlie: 2 wild\n\n\n", []routeEnt{
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "blue", line: 3},
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "orange", line: 3},
+			routeEnt{name1: "alpha", name2: "charlie", dist: 2, color: "wild", line: 6},
+		}},
+		// no end-of-line on last line:
+		{"alpha - bravo: 2 wild\nalpha - charlie: 2 wild", []routeEnt{
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "wild", line: 1},
+			routeEnt{name1: "alpha", name2: "charlie", dist: 2, color: "wild", line: 2},
+		}},
+		// tunnels and ferries:
+		{"alpha - bravo: 2 wild tunnel, 1 wild ferry\nalpha - charlie: 3 red ferry=2 tunnel\n", []routeEnt{
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "wild", tunnel: true, line: 1},
+			routeEnt{name1: "alpha", name2: "bravo", dist: 1, color: "wild", ferries: 1, line: 1},
+			routeEnt{name1: "alpha", name2: "charlie", dist: 3, color: "red", tunnel: true, ferries: 2, line: 2},
+		}},
+		// empty input:
+		{"", []routeEnt{}},
+	}
+
+	// run test cases:
+	for _, tc := range tcs {
+		if routes, err := loadRouteEntries(strings.NewReader(tc.inText)); err != nil {
+			t.Errorf("got error loading %q: %s", tc.inText, err)
+		} else if len(routes) != len(tc.expEnts) {
+			t.Errorf("expected %v route(s) but got %v (%q, %v)", len(tc.expEnts), len(routes), tc.inText, routes)
+		} else {
+			for i, exp := range tc.expEnts {
+				got := routes[i]
+				if exp != got {
+					t.Errorf("expected route %v to be %v but got %v", i, exp, got)
+				}
+			}
+		}
+	}
+}
+
+func TestLoadRouteEntriesErrors(t *testing.T) {
+	for _, inText := range []string{
+		"alpha bravo: 2 wild\n",
+		"alpha - bravo 2 wild\n",
+		"alpha - bravo: 2\n",
+		"alpha - bravo: x wild\n",
+		"alpha - bravo: 2 wild bridge\n",
+		"alpha - bravo: 2 wild ferry=0\n",
+		"alpha - bravo: 2 wild ferry=x\n",
+		"alpha - bravo: 1 wild ferry=3\n",
+	} {
+		if _, err := loadRouteEntries(strings.NewReader(inText)); err == nil {
+			t.Errorf("expected error loading %q but got none", inText)
+		}
+	}
+}
+
+func TestParseErrorPositions(t *testing.T) {
+	loadRoutes := func(s string) error { _, err := loadRouteEntries(strings.NewReader(s)); return err }
+	loadDests := func(s string) error { _, err := loadDestEntries(strings.NewReader(s)); return err }
+	loadRegions := func(s string) error { _, err := loadRegionEntries(strings.NewReader(s)); return err }
+	type tc struct {
+		load    func(string) error
+		inText  string
+		expLine int
+		expCol  int
+	}
+	tcs := []tc{
+		{loadRoutes, "alpha bravo: 2 wild\n", 1, 1},
+		{loadRoutes, "alpha - bravo: 2 wild\n  alpha - charlie 2 wild\n", 2, 11},
+		{loadRoutes, "alpha - bravo: 2 wild, x red\n", 1, 24},
+		{loadRoutes, "alpha - bravo: 2 wild, 1 red bridge\n", 1, 30},
+		{loadRoutes, "alpha - bravo: 2 wild\nalpha - charlie: 1 wild ferry=3\n", 2, 25},
+		{loadRoutes, "alpha - bravo: 2\n", 1, 17},
+		{loadRoutes, "\n\n - bravo: 2 wild\n", 3, 2},
+		{loadRoutes, "[coordinates]\nalpha: 1, y\n", 2, 11},
+		{loadRoutes, "[cities]\n", 1, 1},
+		{loadDests, "alpha - bravo: x\n", 1, 16},
+		{loadRegions, "West: alpha, , bravo\n", 1, 13},
+	}
+	for _, tc := range tcs {
+		err := tc.load(tc.inText)
+		pe, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("expected parse error loading %q but got %v", tc.inText, err)
+		} else if pe.Line != tc.expLine || pe.Column != tc.expCol {
+			t.Errorf("expected error loading %q at %d:%d but got %d:%d (%s)", tc.inText, tc.expLine, tc.expCol, pe.Line,
+				pe.Column, pe)
+		}
+	}
+}
+
+func TestLoadFromFileErrors(t *testing.T) {
+	dir := t.TempDir()
+	filename := filepath.Join(dir, "dests.dat")
+	if err := os.WriteFile(filename, []byte("alpha - bravo: 2\nalpha - charlie 2\n"), 0666); err != nil {
+		t.Fatal(err)
+	}
+	_, err := loadDestEntriesFromFile(filename)
+	pe, ok := err.(*ParseError)
+	if !ok {
+		t.Fatalf("expected parse error but got %v", err)
+	}
+	if pe.File != filename || pe.Line != 2 || pe.Column != 9 {
+		t.Errorf("expected error at %s:2:9 but got %s:%d:%d", filename, pe.File, pe.Line, pe.Column)
+	}
+	if exp := filename + ":2:9: missing ':'"; pe.Error() != exp {
+		t.Errorf("expected message %q but got %q", exp, pe.Error())
+	}
+
+	missing := filepath.Join(dir, "missing.dat")
+	if _, err = loadMapFromFile(missing); err == nil || !strings.Contains(err.Error(), missing) {
+		t.Errorf("expected error naming %s but got %v", missing, err)
+	}
+}
+
+func TestLoadRouteEntriesReal(t *testing.T) {
+	mustLoadRouteEntriesFromFile("../routes.dat")
+}
+
+func TestLoadMapEntriesParser(t *testing.T) {
+	inText := `
+		alpha - bravo: 2 wild
+
+		[coordinates]
+		alpha: 1, 2.5
+		bravo: -3,4
+
+		[routes]
+		bravo - charlie: 1 red
+	`
+	routeEnts, coordEnts, err := loadMapEntries(strings.NewReader(inText))
+	if err != nil {
+		t.Fatalf("got error loading %q: %s", inText, err)
+	}
+	expRoutes := []routeEnt{
+		routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "wild", line: 2},
+		routeEnt{name1: "bravo", name2: "charlie", dist: 1, color: "red", line: 9},
+	}
+	expCoords := []coordEnt{
+		coordEnt{"alpha", 1, 2.5},
+		coordEnt{"bravo", -3, 4},
+	}
+	if len(routeEnts) != len(expRoutes) {
+		t.Fatalf("expected %d route(s) but got %d (%v)", len(expRoutes), len(routeEnts), routeEnts)
+	}
+	for i, exp := range expRoutes {
+		if routeEnts[i] != exp {
+			t.Errorf("expected route %v to be %v but got %v", i, exp, routeEnts[i])
+		}
+	}
+	if len(coordEnts) != len(expCoords) {
+		t.Fatalf("expected %d coordinate(s) but got %d (%v)", len(expCoords), len(coordEnts), coordEnts)
+	}
+	for i, exp := range expCoords {
+		if coordEnts[i] != exp {
+			t.Errorf("expected coordinate %v to be %v but got %v", i, exp, coordEnts[i])
+		}
+	}
+
+	// errors:
+	for _, inText := range []string{
+		"[coordinates\n",
+		"[cities]\n",
+		"[coordinates]\nalpha 1, 2\n",
+		"[coordinates]\nalpha: 1 2\n",
+		"[coordinates]\nalpha: x, 2\n",
+		"[coordinates]\nalpha: 1, y\n",
+	} {
+		if _, _, err := loadMapEntries(strings.NewReader(inText)); err == nil {
+			t.Errorf("expected error loading %q but got none", inText)
+		}
+	}
+}
+
+func TestLoadMapEntriesReal(t *testing.T) {
+	routeEnts, coordEnts := mustLoadMapEntriesFromFile("../routes.dat")
+	u := newUnivNoPaths(routeEnts)
+	if err := u.setCoords(coordEnts); err != nil {
+		t.Fatalf("got error setting coordinates: %s", err)
+	}
+	if !u.Located() {
+		t.Errorf("not every city has coordinates")
+	}
+}
+
+// Loaders for tests, which panic on error.
+
+func mustLoadRouteEntriesFromString(s string) []routeEnt {
+	ents, err := loadRouteEntries(strings.NewReader(s))
+	if err != nil {
+		panic(err)
+	}
+	return ents
+}
+
+func mustLoadRouteEntriesFromFile(filename string) []routeEnt {
+	m := mustLoadMapFromFile(filename)
+	return m.routeEnts
+}
+
+func mustLoadMapEntriesFromFile(filename string) ([]routeEnt, []coordEnt) {
+	m := mustLoadMapFromFile(filename)
+	return m.routeEnts, m.coordEnts
+}
+
+func mustLoadMapFromFile(filename string) *Map {
+	m, err := loadMapFromFile(filename)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func mustLoadDestEntriesFromFile(filename string) []destEnt {
+	ents, err := loadDestEntriesFromFile(filename)
+	if err != nil {
+		panic(err)
+	}
+	return ents
+}
+
+func mustLoadRegionEntriesFromFile(filename string) []regionEnt {
+	ents, err := loadRegionEntriesFromFile(filename)
+	if err != nil {
+		panic(err)
+	}
+	return ents
+}
@@ -0,0 +1,119 @@
+package ttr
+
+import (
+	"io"
+	"sort"
+)
+
+// A claim is a route on the board that a player has claimed.
+type Claim struct {
+	City1 *City
+	City2 *City
+	Route *Route
+}
+
+// Loads claimed routes from a file in route-file format, e.g.,
+// "Denver - Omaha: 4 pink". Each route must be one on the board, and naming a
+// double route's color twice claims both of its routes.
+func LoadClaims(u *Univ, filename string) (claims []*Claim, err error) {
+	err = loadFile(filename, func(r io.Reader) (err error) {
+		claims, err = loadClaims(u, r)
+		return
+	})
+	return
+}
+
+func loadClaims(u *Univ, r io.Reader) ([]*Claim, error) {
+	ents, err := loadRouteEntries(r)
+	if err != nil {
+		return nil, err
+	}
+	return newClaimsFromRouteEntries(u, ents, make(map[*Route]bool))
+}
+
+// Matches route entries to the board's routes, marking each route claimed. It's
+// an error if an entry matches no route that isn't already claimed. The
+// entries' tunnel and ferry attributes needn't be given.
+func newClaimsFromRouteEntries(u *Univ, ents []routeEnt, claimed map[*Route]bool) (claims []*Claim, err error) {
+	for _, ent := range ents {
+		c1 := u.cityByName[ent.name1]
+		if c1 == nil {
+			return nil, newParseError(ent.line, 0, "city %q doesn't exist", ent.name1)
+		}
+		c2 := u.cityByName[ent.name2]
+		if c2 == nil {
+			return nil, newParseError(ent.line, 0, "city %q doesn't exist", ent.name2)
+		}
+		var route *Route
+		found := false
+		for _, r := range c1.routes[c2] {
+			if r.Dist == ent.dist && r.Color == ent.color {
+				found = true
+				if !claimed[r] {
+					route = r
+					break
+				}
+			}
+		}
+		if !found {
+			return nil, newParseError(ent.line, 0, "no %d %s route between %q and %q", ent.dist, ent.color,
+				ent.name1, ent.name2)
+		}
+		if route == nil {
+			return nil, newParseError(ent.line, 0, "%d %s route between %q and %q already claimed", ent.dist,
+				ent.color, ent.name1, ent.name2)
+		}
+		claimed[route] = true
+		claims = append(claims, &Claim{c1, c2, route})
+	}
+	return
+}
+
+// Returns the longest continuous path through the claimed routes, for the
+// Longest Route bonus. The path may pass through a city more than once but may
+// use each route only once, and it's nil if there are no claims. Among paths of
+// equal length, the one found first, starting from the alphabetically first
+// city, wins.
+//
+// The search tries every trail, which is quick for the few dozen routes that
+// a player's trains can claim.
+func LongestTrail(claims []*Claim) *Path {
+	adjClaims := make(map[*City][]*Claim)
+	for _, cl := range claims {
+		adjClaims[cl.City1] = append(adjClaims[cl.City1], cl)
+		adjClaims[cl.City2] = append(adjClaims[cl.City2], cl)
+	}
+	var cities []*City
+	for c := range adjClaims {
+		cities = append(cities, c)
+	}
+	sort.Slice(cities, func(i, j int) bool { return cities[i].Name < cities[j].Name })
+
+	var best *Path
+	used := make(map[*Claim]bool)
+	var extend func(p *Path)
+	extend = func(p *Path) {
+		if best == nil || p.Dist > best.Dist {
+			best = copyPath(p)
+		}
+		cur := p.Cities[len(p.Cities)-1]
+		for _, cl := range adjClaims[cur] {
+			if used[cl] {
+				continue
+			}
+			next := cl.City2
+			if next == cur {
+				next = cl.City1
+			}
+			used[cl] = true
+			p.appendHop(next, cl.Route)
+			extend(p)
+			p.chopHop()
+			used[cl] = false
+		}
+	}
+	for _, c := range cities {
+		extend(newPath(c))
+	}
+	return best
+}
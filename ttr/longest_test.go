@@ -0,0 +1,78 @@
+package ttr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLoadClaims(t *testing.T) {
+	u := newUnivNoPaths(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 2 red, 2 wild
+		bravo - charlie: 3 blue tunnel
+	`))
+
+	claims, err := loadClaims(u, strings.NewReader("alpha - bravo: 2 wild\ncharlie - bravo: 3 blue\n"))
+	if err != nil {
+		t.Fatalf("got error: %s", err)
+	}
+	if len(claims) != 2 || claims[0].Route != u.cityByName["alpha"].routes[u.cityByName["bravo"]][1] ||
+		!claims[1].Route.Tunnel || claims[1].City1.Name != "charlie" {
+		t.Errorf("got unexpected claims %v", claims)
+	}
+
+	for _, s := range []string{
+		"alpha - delta: 2 red",
+		"alpha - bravo: 2 blue",
+		"bravo - charlie: 3 blue\nbravo - charlie: 3 blue",
+	} {
+		if _, err := loadClaims(u, strings.NewReader(s)); err == nil {
+			t.Errorf("expected error for %q but got none", s)
+		}
+	}
+}
+
+func TestLongestTrail(t *testing.T) {
+	type tc struct {
+		claims string
+		exp    string
+	}
+	tcs := []tc{
+
+		// case: no claims
+		tc{"", "<nil>"},
+
+		// case: the trail goes around the loop and returns to alpha
+		tc{`
+			alpha - echo: 1 wild
+			alpha - bravo: 2 wild
+			bravo - charlie: 2 wild
+			charlie - alpha: 2 wild
+		`, `"alpha"–2,wild–"bravo"–2,wild–"charlie"–2,wild–"alpha"–1,wild–"echo"`},
+
+		// case: the trail uses both routes of a double route
+		tc{`
+			alpha - bravo: 2 red, 2 wild
+			bravo - charlie: 1 wild
+		`, `"bravo"–2,red–"alpha"–2,wild–"bravo"–1,wild–"charlie"`},
+	}
+
+	u := newUnivNoPaths(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 2 red, 2 wild
+		alpha - echo: 1 wild
+		bravo - charlie: 2 wild, 1 wild
+		charlie - alpha: 2 wild
+	`))
+	for i, tc := range tcs {
+		claims, err := loadClaims(u, strings.NewReader(tc.claims))
+		if err != nil {
+			t.Fatalf("case %d: got error loading claims: %s", i, err)
+		}
+		got := "<nil>"
+		if p := LongestTrail(claims); p != nil {
+			got = p.String()
+		}
+		if got != tc.exp {
+			t.Errorf("case %d: expected %s but got %s", i, tc.exp, got)
+		}
+	}
+}
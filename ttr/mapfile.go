@@ -0,0 +1,432 @@
+package ttr
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// A map file is a single JSON document describing a whole game board: its
+// name, cities, routes, and the official destination deck. Lines whose first
+// non-blank characters are "//" are comments. For example:
+//
+//	// The original game board.
+//	{
+//		"name": "USA",
+//		"cities": [
+//			{"name": "Vancouver", "x": 14, "y": 27},
+//			{"name": "Calgary", "x": 83, "y": 10}
+//		],
+//		"routes": [
+//			{"from": "Vancouver", "to": "Calgary", "length": 3, "color": "wild"},
+//			{"from": "Calgary", "to": "Helena", "length": 4, "color": "wild", "tunnel": true}
+//		],
+//		"destinations": [
+//			{"from": "Vancouver", "to": "Calgary", "value": 3}
+//		],
+//		"regions": [
+//			{"name": "West", "cities": ["Vancouver", "Calgary"]}
+//		]
+//	}
+//
+// Cities that appear in routes needn't be listed in "cities" unless they have
+// coordinates, but if a city is listed then it must appear in some route.
+//
+// A route may be a tunnel ("tunnel": true) or a ferry ("ferries": N, the number
+// of locomotives required, at most the route's length). The optional
+// "doubleRouteMinPlayers" is the fewest players for which both routes of a
+// double route are usable, four by default. The optional "stations" is the
+// number of train stations each player has, on boards that use them.
+type mapFile struct {
+	Name                  string          `json:"name"`
+	DoubleRouteMinPlayers int             `json:"doubleRouteMinPlayers,omitempty"`
+	Stations              int             `json:"stations,omitempty"`
+	Cities                []mapFileCity   `json:"cities,omitempty"`
+	Routes                []mapFileRoute  `json:"routes"`
+	Destinations          []mapFileDest   `json:"destinations,omitempty"`
+	Regions               []mapFileRegion `json:"regions,omitempty"`
+}
+
+type mapFileCity struct {
+	Name string   `json:"name"`
+	X    *float64 `json:"x,omitempty"`
+	Y    *float64 `json:"y,omitempty"`
+}
+
+type mapFileRoute struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Length  int    `json:"length"`
+	Color   string `json:"color"`
+	Tunnel  bool   `json:"tunnel,omitempty"`
+	Ferries int    `json:"ferries,omitempty"`
+}
+
+type mapFileDest struct {
+	From  string `json:"from"`
+	To    string `json:"to"`
+	Value int    `json:"value"`
+}
+
+type mapFileRegion struct {
+	Name   string   `json:"name"`
+	Cities []string `json:"cities"`
+}
+
+// A map is everything loaded from a map file, in either format: the board, its
+// official destinations, and its regions. NewUniv makes a universe from a map.
+type Map struct {
+	Name                  string
+	file                  string // where the map was loaded from, for error messages
+	destFile              string // where the destinations were loaded from, if not the map's file
+	doubleRouteMinPlayers int    // zero for the default
+	stations              int    // per player, zero if the board has none
+	routeEnts             []routeEnt
+	coordEnts             []coordEnt
+	destEnts              []destEnt
+	regionEnts            []regionEnt
+}
+
+func (m *Map) NumRoutes() int {
+	return len(m.routeEnts)
+}
+
+func (m *Map) NumDests() int {
+	return len(m.destEnts)
+}
+
+// Returns the map's destinations, which must be in the given universe, made
+// from the map.
+func (m *Map) Dests(u *Univ) ([]*Dest, error) {
+	dests, err := newDestsFromDestEntries(u, m.destEnts)
+	if m.destFile != "" {
+		return dests, withFile(err, m.destFile)
+	}
+	return dests, withFile(err, m.file)
+}
+
+// Replaces the map's destinations with those loaded from a destination file.
+func (m *Map) ReplaceDests(filename string) error {
+	ents, err := loadDestEntriesFromFile(filename)
+	if err != nil {
+		return err
+	}
+	m.destEnts = ents
+	m.destFile = filename
+	return nil
+}
+
+// Returns the map's regions, which must be in the given universe, made from
+// the map.
+func (m *Map) Regions(u *Univ) ([]*Region, error) {
+	regions, err := newRegionsFromRegionEntries(u, m.regionEnts)
+	return regions, withFile(err, m.file)
+}
+
+// Replaces the map's regions with those loaded from a region file.
+func (m *Map) ReplaceRegions(filename string) error {
+	ents, err := loadRegionEntriesFromFile(filename)
+	if err != nil {
+		return err
+	}
+	m.regionEnts = ents
+	return nil
+}
+
+// Checks the map and its destinations for likely mistakes.
+func (m *Map) Validate() []MapProblem {
+	destFile := m.destFile
+	if destFile == "" {
+		destFile = m.file
+	}
+	return validateMap(m, m.file, m.destEnts, destFile)
+}
+
+// The line on which each element of each array in a map file begins.
+type mapFileLines struct {
+	cities       []int
+	routes       []int
+	destinations []int
+	regions      []int
+}
+
+func loadJSONMap(r io.Reader) (m *Map, err error) {
+
+	// strip comments, keeping line numbers intact for error messages:
+	var stripped bytes.Buffer
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(strings.TrimSpace(line), "//") {
+			line = ""
+		}
+		stripped.WriteString(line)
+		stripped.WriteByte('\n')
+	}
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("input error: %s", err)
+	}
+
+	var mf mapFile
+	var lines mapFileLines
+	if err = decodeMapFile(stripped.Bytes(), &mf, &lines); err != nil {
+		return nil, err
+	}
+
+	if mf.DoubleRouteMinPlayers < 0 {
+		return nil, newParseError(0, 0, "invalid doubleRouteMinPlayers %d", mf.DoubleRouteMinPlayers)
+	}
+	if mf.Stations < 0 {
+		return nil, newParseError(0, 0, "invalid stations %d", mf.Stations)
+	}
+	m = &Map{Name: mf.Name, doubleRouteMinPlayers: mf.DoubleRouteMinPlayers, stations: mf.Stations}
+	routeCities := make(map[string]bool)
+	for i, r := range mf.Routes {
+		line := lines.routes[i]
+		if r.From == "" || r.To == "" {
+			return nil, newParseError(line, 0, "missing city name in route")
+		}
+		if r.Color == "" {
+			return nil, newParseError(line, 0, "missing color in route %s - %s", r.From, r.To)
+		}
+		if r.Ferries < 0 {
+			return nil, newParseError(line, 0, "invalid ferry count %d in route %s - %s", r.Ferries, r.From, r.To)
+		}
+		if r.Ferries > r.Length {
+			return nil, newParseError(line, 0, "ferry count %d exceeds length %d in route %s - %s", r.Ferries, r.Length,
+				r.From, r.To)
+		}
+		m.routeEnts = append(m.routeEnts, routeEnt{name1: r.From, name2: r.To, dist: r.Length, color: r.Color,
+			tunnel: r.Tunnel, ferries: r.Ferries, line: line})
+		routeCities[r.From] = true
+		routeCities[r.To] = true
+	}
+	for i, c := range mf.Cities {
+		if !routeCities[c.Name] {
+			return nil, newParseError(lines.cities[i], 0, "city %q has no routes", c.Name)
+		}
+		if (c.X == nil) != (c.Y == nil) {
+			return nil, newParseError(lines.cities[i], 0, "city %q must have both or neither of x and y", c.Name)
+		}
+		if c.X != nil {
+			m.coordEnts = append(m.coordEnts, coordEnt{name: c.Name, x: *c.X, y: *c.Y})
+		}
+	}
+	for i, d := range mf.Destinations {
+		m.destEnts = append(m.destEnts, destEnt{name1: d.From, name2: d.To, value: d.Value, line: lines.destinations[i]})
+	}
+	for _, rgn := range mf.Regions {
+		m.regionEnts = append(m.regionEnts, regionEnt{name: rgn.Name, cityNames: rgn.Cities})
+	}
+
+	return
+}
+
+// Decodes a map file much as json.Unmarshal would, except that it also records
+// the line on which each array element begins, for diagnostics, and any error
+// is a parse error giving the line and column.
+func decodeMapFile(data []byte, mf *mapFile, lines *mapFileLines) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	posAt := func(offset int64) (line, col int) {
+		before := data[:offset]
+		line = bytes.Count(before, []byte("\n")) + 1
+		col = len(before) - (bytes.LastIndexByte(before, '\n') + 1) + 1
+		return
+	}
+	// Returns the offset of the next value, skipping the separator after the
+	// previous token.
+	nextValue := func() int64 {
+		offset := dec.InputOffset()
+		for offset < int64(len(data)) && strings.IndexByte(" \t\r\n,:", data[offset]) != -1 {
+			offset++
+		}
+		return offset
+	}
+	// Converts an error from decoding the value at the given offset.
+	parseError := func(err error, start int64) error {
+		offset := start
+		switch e := err.(type) {
+		case *json.SyntaxError:
+			offset = e.Offset
+		case *json.UnmarshalTypeError:
+			offset = start + e.Offset // relative to the value
+		}
+		msg := strings.TrimPrefix(err.Error(), "json: ")
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			msg = "unexpected end of input"
+			offset = int64(len(data))
+		}
+		line, col := posAt(offset)
+		return newParseError(line, col, "%s", msg)
+	}
+	expectDelim := func(delim json.Delim) error {
+		start := nextValue()
+		tok, err := dec.Token()
+		if err != nil {
+			return parseError(err, start)
+		}
+		if tok != delim {
+			line, col := posAt(dec.InputOffset())
+			return newParseError(line, col, "expected %q", delim)
+		}
+		return nil
+	}
+	decodeArray := func(elemLines *[]int, decodeElem func() error) error {
+		if err := expectDelim('['); err != nil {
+			return err
+		}
+		for dec.More() {
+			start := nextValue()
+			line, _ := posAt(start)
+			*elemLines = append(*elemLines, line)
+			if err := decodeElem(); err != nil {
+				return parseError(err, start)
+			}
+		}
+		return expectDelim(']')
+	}
+
+	if err := expectDelim('{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return parseError(err, dec.InputOffset())
+		}
+		start := nextValue()
+		switch key := tok.(string); key {
+		case "name":
+			err = dec.Decode(&mf.Name)
+		case "doubleRouteMinPlayers":
+			err = dec.Decode(&mf.DoubleRouteMinPlayers)
+		case "stations":
+			err = dec.Decode(&mf.Stations)
+		case "cities":
+			err = decodeArray(&lines.cities, func() error {
+				var c mapFileCity
+				err := dec.Decode(&c)
+				mf.Cities = append(mf.Cities, c)
+				return err
+			})
+		case "routes":
+			err = decodeArray(&lines.routes, func() error {
+				var r mapFileRoute
+				err := dec.Decode(&r)
+				mf.Routes = append(mf.Routes, r)
+				return err
+			})
+		case "destinations":
+			err = decodeArray(&lines.destinations, func() error {
+				var d mapFileDest
+				err := dec.Decode(&d)
+				mf.Destinations = append(mf.Destinations, d)
+				return err
+			})
+		case "regions":
+			err = decodeArray(&lines.regions, func() error {
+				var rgn mapFileRegion
+				err := dec.Decode(&rgn)
+				mf.Regions = append(mf.Regions, rgn)
+				return err
+			})
+		default:
+			line, col := posAt(dec.InputOffset())
+			return newParseError(line, col, "unknown field %q", key)
+		}
+		if _, ok := err.(*ParseError); err != nil && !ok {
+			err = parseError(err, start)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return expectDelim('}')
+}
+
+func WriteJSONMap(w io.Writer, m *Map) error {
+	mf := mapFile{Name: m.Name, DoubleRouteMinPlayers: m.doubleRouteMinPlayers, Stations: m.stations}
+	coords := make(map[string]coordEnt)
+	for _, ent := range m.coordEnts {
+		coords[ent.name] = ent
+	}
+	seen := make(map[string]bool)
+	addCity := func(name string) {
+		if seen[name] {
+			return
+		}
+		seen[name] = true
+		c := mapFileCity{Name: name}
+		if ent, ok := coords[name]; ok {
+			x, y := ent.x, ent.y
+			c.X, c.Y = &x, &y
+		}
+		mf.Cities = append(mf.Cities, c)
+	}
+	for _, ent := range m.routeEnts {
+		addCity(ent.name1)
+		addCity(ent.name2)
+		mf.Routes = append(mf.Routes, mapFileRoute{From: ent.name1, To: ent.name2, Length: ent.dist, Color: ent.color,
+			Tunnel: ent.tunnel, Ferries: ent.ferries})
+	}
+	for _, ent := range m.destEnts {
+		mf.Destinations = append(mf.Destinations, mapFileDest{From: ent.name1, To: ent.name2, Value: ent.value})
+	}
+	for _, ent := range m.regionEnts {
+		mf.Regions = append(mf.Regions, mapFileRegion{Name: ent.name, Cities: ent.cityNames})
+	}
+
+	// Write one city, route, or destination per line, which is easier to read and
+	// edit by hand than fully indented JSON.
+	var buf bytes.Buffer
+	name, err := json.Marshal(mf.Name)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(&buf, "{\n\t\"name\": %s", name)
+	if mf.DoubleRouteMinPlayers != 0 {
+		fmt.Fprintf(&buf, ",\n\t\"doubleRouteMinPlayers\": %d", mf.DoubleRouteMinPlayers)
+	}
+	if mf.Stations != 0 {
+		fmt.Fprintf(&buf, ",\n\t\"stations\": %d", mf.Stations)
+	}
+	writeArray := func(key string, n int, elem func(i int) interface{}) error {
+		if n == 0 {
+			return nil
+		}
+		fmt.Fprintf(&buf, ",\n\t%q: [\n", key)
+		for i := 0; i < n; i++ {
+			b, err := json.Marshal(elem(i))
+			if err != nil {
+				return err
+			}
+			sep := ","
+			if i == n-1 {
+				sep = ""
+			}
+			fmt.Fprintf(&buf, "\t\t%s%s\n", b, sep)
+		}
+		fmt.Fprintf(&buf, "\t]")
+		return nil
+	}
+	if err = writeArray("cities", len(mf.Cities), func(i int) interface{} { return mf.Cities[i] }); err != nil {
+		return err
+	}
+	if err = writeArray("routes", len(mf.Routes), func(i int) interface{} { return mf.Routes[i] }); err != nil {
+		return err
+	}
+	if err = writeArray("destinations", len(mf.Destinations), func(i int) interface{} { return mf.Destinations[i] }); err != nil {
+		return err
+	}
+	if err = writeArray("regions", len(mf.Regions), func(i int) interface{} { return mf.Regions[i] }); err != nil {
+		return err
+	}
+	fmt.Fprintf(&buf, "\n}\n")
+
+	_, err = w.Write(buf.Bytes())
+	return err
+}
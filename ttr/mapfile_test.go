@@ -0,0 +1,156 @@
+package ttr
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestLoadJSONMap(t *testing.T) {
+	inText := `// A tiny map.
+	{
+		"name": "Tiny",
+		// Cities with coordinates.
+		"cities": [
+			{"name": "alpha", "x": 1, "y": 2},
+			{"name": "bravo"}
+		],
+		"routes": [
+			{"from": "alpha", "to": "bravo", "length": 2, "color": "blue"},
+			{"from": "alpha", "to": "bravo", "length": 2, "color": "wild"},
+			{"from": "bravo", "to": "charlie", "length": 1, "color": "red"}
+		],
+		"destinations": [
+			{"from": "alpha", "to": "charlie", "value": 3}
+		]
+	}`
+	m, err := loadJSONMap(strings.NewReader(inText))
+	if err != nil {
+		t.Fatalf("got error loading map: %s", err)
+	}
+	if m.Name != "Tiny" {
+		t.Errorf("expected name %q but got %q", "Tiny", m.Name)
+	}
+	expRoutes := []routeEnt{
+		routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "blue", line: 10},
+		routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "wild", line: 11},
+		routeEnt{name1: "bravo", name2: "charlie", dist: 1, color: "red", line: 12},
+	}
+	if len(m.routeEnts) != len(expRoutes) {
+		t.Fatalf("expected %d route(s) but got %d (%v)", len(expRoutes), len(m.routeEnts), m.routeEnts)
+	}
+	for i, exp := range expRoutes {
+		if m.routeEnts[i] != exp {
+			t.Errorf("expected route %v to be %v but got %v", i, exp, m.routeEnts[i])
+		}
+	}
+	if len(m.coordEnts) != 1 || m.coordEnts[0] != (coordEnt{"alpha", 1, 2}) {
+		t.Errorf("got unexpected coordinates %v", m.coordEnts)
+	}
+	if len(m.destEnts) != 1 || m.destEnts[0] != (destEnt{"alpha", "charlie", 3, 15}) {
+		t.Errorf("got unexpected destinations %v", m.destEnts)
+	}
+}
+
+func TestLoadJSONMapErrors(t *testing.T) {
+	for _, inText := range []string{
+		``,
+		`{"name": "x", "routes": [`,
+		`{"name": "x", "bogus": 1}`,
+		`{"name": "x", "doubleRouteMinPlayers": -1}`,
+		`{"name": "x", "stations": -1}`,
+		`{"routes": [{"from": "alpha", "to": "bravo", "length": 1, "color": "red", "ferries": -1}]}`,
+		`{"routes": [{"from": "alpha", "to": "bravo", "length": 1, "color": "wild", "ferries": 3}]}`,
+		`{"routes": [{"from": "alpha", "length": 1, "color": "red"}]}`,
+		`{"routes": [{"from": "alpha", "to": "bravo", "length": 1}]}`,
+		`{"cities": [{"name": "charlie"}], "routes": [{"from": "alpha", "to": "bravo", "length": 1, "color": "red"}]}`,
+		`{"cities": [{"name": "alpha", "x": 1}], "routes": [{"from": "alpha", "to": "bravo", "length": 1, "color": "red"}]}`,
+	} {
+		if _, err := loadJSONMap(strings.NewReader(inText)); err == nil {
+			t.Errorf("expected error loading %q but got none", inText)
+		}
+	}
+}
+
+func TestLoadJSONMapErrorPositions(t *testing.T) {
+	type tc struct {
+		inText  string
+		expLine int
+		expCol  int
+	}
+	tcs := []tc{
+		{"{\n\t\"name\": \"x\",\n\t\"bogus\": 1\n}", 3, 9},
+		{"{\n\t\"routes\": [\n\t\t{\"from\": \"alpha\" \"to\": \"bravo\"}\n\t]\n}", 3, 21},
+		{"{\n\t\"routes\": [\n\t\t{\"from\": \"alpha\", \"to\": \"bravo\", \"length\": 1, \"color\": \"red\"},\n" +
+			"\t\t{\"from\": \"alpha\", \"to\": \"bravo\", \"length\": 1}\n\t]\n}", 4, 0},
+		{"{\n\t\"routes\": [\n\t\t{\"from\": \"alpha\", \"to\": \"bravo\", \"length\": 1, \"color\": \"red\"},\n" +
+			"\t\t{\"from\": \"alpha\", \"to\": \"charlie\", \"length\": 1, \"color\": \"wild\", \"ferries\": 3}\n\t]\n}", 4, 0},
+	}
+	for _, tc := range tcs {
+		_, err := loadJSONMap(strings.NewReader(tc.inText))
+		pe, ok := err.(*ParseError)
+		if !ok {
+			t.Errorf("expected parse error loading %q but got %v", tc.inText, err)
+		} else if pe.Line != tc.expLine || pe.Column != tc.expCol {
+			t.Errorf("expected error loading %q at %d:%d but got %d:%d (%s)", tc.inText, tc.expLine, tc.expCol, pe.Line,
+				pe.Column, pe)
+		}
+	}
+}
+
+func TestWriteJSONMap(t *testing.T) {
+	m := &Map{
+		Name:                  "Tiny \"map\"",
+		doubleRouteMinPlayers: 3,
+		stations:              2,
+		routeEnts: []routeEnt{
+			routeEnt{name1: "alpha", name2: "bravo", dist: 2, color: "blue", tunnel: true, ferries: 1},
+			routeEnt{name1: "bravo", name2: "charlie", dist: 1, color: "red"},
+		},
+		coordEnts: []coordEnt{
+			coordEnt{"bravo", 0.5, -3},
+		},
+		destEnts: []destEnt{
+			destEnt{name1: "alpha", name2: "charlie", value: 3},
+		},
+		regionEnts: []regionEnt{
+			regionEnt{"west", []string{"alpha", "bravo"}},
+		},
+	}
+	var buf bytes.Buffer
+	if err := WriteJSONMap(&buf, m); err != nil {
+		t.Fatalf("got error writing map: %s", err)
+	}
+	got, err := loadJSONMap(&buf)
+	if err != nil {
+		t.Fatalf("got error loading map: %s", err)
+	}
+	// the line numbers depend on the layout of the output, which doesn't matter:
+	for i := range got.routeEnts {
+		got.routeEnts[i].line = 0
+	}
+	for i := range got.destEnts {
+		got.destEnts[i].line = 0
+	}
+	if got.Name != m.Name {
+		t.Errorf("expected name %q but got %q", m.Name, got.Name)
+	}
+	if got.doubleRouteMinPlayers != m.doubleRouteMinPlayers {
+		t.Errorf("expected double-route minimum %d but got %d", m.doubleRouteMinPlayers, got.doubleRouteMinPlayers)
+	}
+	if got.stations != m.stations {
+		t.Errorf("expected %d stations but got %d", m.stations, got.stations)
+	}
+	if len(got.routeEnts) != 2 || got.routeEnts[0] != m.routeEnts[0] || got.routeEnts[1] != m.routeEnts[1] {
+		t.Errorf("expected routes %v but got %v", m.routeEnts, got.routeEnts)
+	}
+	if len(got.coordEnts) != 1 || got.coordEnts[0] != m.coordEnts[0] {
+		t.Errorf("expected coordinates %v but got %v", m.coordEnts, got.coordEnts)
+	}
+	if len(got.destEnts) != 1 || got.destEnts[0] != m.destEnts[0] {
+		t.Errorf("expected destinations %v but got %v", m.destEnts, got.destEnts)
+	}
+	if len(got.regionEnts) != 1 || got.regionEnts[0].name != "west" || len(got.regionEnts[0].cityNames) != 2 {
+		t.Errorf("expected regions %v but got %v", m.regionEnts, got.regionEnts)
+	}
+}
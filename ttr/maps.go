@@ -0,0 +1,53 @@
+package ttr
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// The bundled maps are JSON map files for the official game boards, built into
+// the program so that they're available without any data files. A bundled map
+// is named after its file, e.g., "usa" for maps/usa.json.
+//
+//go:embed maps/*.json
+var bundledMapFS embed.FS
+
+func BundledMapNames() (names []string) {
+	files, err := bundledMapFS.ReadDir("maps")
+	if err != nil {
+		panic(err)
+	}
+	for _, f := range files {
+		names = append(names, strings.TrimSuffix(f.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return
+}
+
+// Loads the bundled map with the given name, ignoring case.
+func LoadBundledMap(name string) (*Map, error) {
+	file := "maps/" + strings.ToLower(name) + ".json"
+	b, err := bundledMapFS.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("no bundled map named %q", name)
+	}
+	m, err := loadJSONMap(bytes.NewReader(b))
+	if err != nil {
+		return nil, withFile(err, file)
+	}
+	m.file = file
+	return m, nil
+}
+
+// Loads a map from a file or, if no such file exists, from the bundled map
+// with that name.
+func LoadMap(nameOrFile string) (*Map, error) {
+	if _, err := os.Stat(nameOrFile); err != nil && !strings.ContainsAny(nameOrFile, "./\\") {
+		return LoadBundledMap(nameOrFile)
+	}
+	return loadMapFromFile(nameOrFile)
+}
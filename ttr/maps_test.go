@@ -0,0 +1,94 @@
+package ttr
+
+import (
+	"testing"
+)
+
+func TestBundledMaps(t *testing.T) {
+	names := BundledMapNames()
+	if len(names) == 0 {
+		t.Fatalf("no bundled maps")
+	}
+	for _, name := range names {
+		m, err := LoadBundledMap(name)
+		if err != nil {
+			t.Errorf("got error loading bundled map %q: %s", name, err)
+			continue
+		}
+		u, err := NewUniv(m)
+		if err != nil {
+			t.Errorf("got error creating universe for bundled map %q: %s", name, err)
+			continue
+		}
+		if !u.Located() {
+			t.Errorf("bundled map %q doesn't have coordinates for every city", name)
+		}
+		if _, err := newDestsFromDestEntries(u, m.destEnts); err != nil {
+			t.Errorf("bundled map %q has invalid destinations: %s", name, err)
+		}
+		if _, err := newRegionsFromRegionEntries(u, m.regionEnts); err != nil {
+			t.Errorf("bundled map %q has invalid regions: %s", name, err)
+		}
+	}
+}
+
+func TestLoadBundledMap(t *testing.T) {
+	m, err := LoadBundledMap("USA")
+	if err != nil {
+		t.Fatalf("got error loading bundled map: %s", err)
+	}
+
+	// check: the bundled map matches the legacy data files
+	routeEnts, coordEnts := mustLoadMapEntriesFromFile("../routes.dat")
+	destEnts := mustLoadDestEntriesFromFile("../destinations.dat")
+	if len(m.routeEnts) != len(routeEnts) || len(m.coordEnts) != len(coordEnts) || len(m.destEnts) != len(destEnts) {
+		t.Errorf("bundled map has %d routes, %d coordinates, %d destinations but expected %d, %d, %d",
+			len(m.routeEnts), len(m.coordEnts), len(m.destEnts), len(routeEnts), len(coordEnts), len(destEnts))
+	}
+
+	// check: the other boards have their counts, stations, and rules
+	type tc struct {
+		name                  string
+		routes, dests         int
+		stations              int
+		tunnels, ferries      int
+		doubleRouteMinPlayers int
+	}
+	for _, c := range []tc{
+		{"europe", 101, 46, 3, 18, 13, 0},
+		{"nordic", 74, 46, 0, 6, 14, 3},
+		{"switzerland", 52, 34, 0, 12, 0, 3},
+		{"india", 64, 50, 0, 0, 0, 0},
+	} {
+		m, err = LoadBundledMap(c.name)
+		if err != nil {
+			t.Errorf("got error loading bundled map %q: %s", c.name, err)
+			continue
+		}
+		tunnels, ferries := 0, 0
+		for _, ent := range m.routeEnts {
+			if ent.tunnel {
+				tunnels++
+			}
+			if ent.ferries > 0 {
+				ferries++
+			}
+		}
+		got := tc{c.name, m.NumRoutes(), m.NumDests(), m.stations, tunnels, ferries, m.doubleRouteMinPlayers}
+		if got != c {
+			t.Errorf("expected %+v but got %+v", c, got)
+		}
+	}
+
+	if _, err := LoadBundledMap("atlantis"); err == nil {
+		t.Errorf("expected error loading unknown map but got none")
+	}
+}
+
+func mustLoadMap(nameOrFile string) *Map {
+	m, err := LoadMap(nameOrFile)
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
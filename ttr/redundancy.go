@@ -0,0 +1,83 @@
+package ttr
+
+// Measures of how hard it is for opponents to block a destination.
+type Redundancy struct {
+	// The number of paths between the cities such that no two paths share a
+	// link.
+	DisjointPaths int
+
+	// The fewest usable routes that opponents must claim to disconnect the
+	// cities.
+	MinCut int
+
+	// The links that every path between the cities crosses, in order along the
+	// shortest path. Claiming every route of one of them blocks the destination.
+	Bottlenecks [][2]*City
+}
+
+// Returns how hard it is to block the connection between two cities in a game
+// with the given number of players, which determines whether both routes of a
+// double route are usable.
+func (u *Univ) Redundancy(c1, c2 *City, players int) *Redundancy {
+	red := &Redundancy{
+		DisjointPaths: maxFlow(c1, c2, func(a, b *City) int { return 1 }),
+		MinCut:        maxFlow(c1, c2, func(a, b *City) int { return len(u.UsableRoutes(a, b, players)) }),
+	}
+	p := c1.shortestDist[c2]
+	if p == nil {
+		return red
+	}
+	for i := 1; i < len(p.Cities); i++ {
+		l := newLink(p.Cities[i-1], p.Cities[i])
+		if findSpurPath(c1, c2, nil, map[link]bool{l: true}) == nil {
+			red.Bottlenecks = append(red.Bottlenecks, [2]*City{p.Cities[i-1], p.Cities[i]})
+		}
+	}
+	return red
+}
+
+// Returns the maximum flow from one city to another, with each link carrying
+// as much flow, in either direction, as the capacity function allows. This is
+// the Edmonds–Karp algorithm: it repeatedly augments along the shortest path
+// with spare capacity.
+func maxFlow(src, dst *City, capacity func(a, b *City) int) (total int) {
+	if src == dst {
+		return 0
+	}
+	flow := make(map[[2]*City]int) // antisymmetric: flow[a, b] == -flow[b, a]
+	for {
+		// breadth-first search for a path with spare capacity:
+		prev := map[*City]*City{src: nil}
+		queue := []*City{src}
+		for len(queue) > 0 && prev[dst] == nil {
+			c := queue[0]
+			queue = queue[1:]
+			for _, adj := range c.Neighbors() {
+				if _, ok := prev[adj]; ok {
+					continue
+				}
+				if capacity(c, adj)-flow[[2]*City{c, adj}] > 0 {
+					prev[adj] = c
+					queue = append(queue, adj)
+				}
+			}
+		}
+		if prev[dst] == nil {
+			return
+		}
+
+		// find and push the most flow the path allows:
+		push := -1
+		for c := dst; c != src; c = prev[c] {
+			spare := capacity(prev[c], c) - flow[[2]*City{prev[c], c}]
+			if push == -1 || spare < push {
+				push = spare
+			}
+		}
+		for c := dst; c != src; c = prev[c] {
+			flow[[2]*City{prev[c], c}] += push
+			flow[[2]*City{c, prev[c]}] -= push
+		}
+		total += push
+	}
+}
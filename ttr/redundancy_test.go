@@ -0,0 +1,56 @@
+package ttr
+
+import (
+	"testing"
+)
+
+func TestRedundancy(t *testing.T) {
+
+	// Every path from alpha to foxtrot crosses the link between delta and echo.
+	// There are two link-disjoint paths from alpha to delta, one of which has a
+	// double route.
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 1 wild, 1 wild
+		bravo - delta: 1 red, 1 blue
+		alpha - charlie: 2 wild
+		charlie - delta: 2 wild
+		delta - echo: 3 green
+		echo - foxtrot: 1 wild
+	`))
+
+	type tc struct {
+		from, to       string
+		players        int
+		expDisjoint    int
+		expMinCut      int
+		expBottlenecks []string
+	}
+	for _, c := range []tc{
+		{"alpha", "delta", 4, 2, 3, nil},
+		{"alpha", "delta", 2, 2, 2, nil},
+		{"alpha", "foxtrot", 4, 1, 1, []string{"delta–echo", "echo–foxtrot"}},
+		{"bravo", "charlie", 4, 2, 2, nil},
+	} {
+		red := u.Redundancy(u.cityByName[c.from], u.cityByName[c.to], c.players)
+		if red.DisjointPaths != c.expDisjoint {
+			t.Errorf("%s to %s: expected %d disjoint paths but got %d", c.from, c.to, c.expDisjoint, red.DisjointPaths)
+		}
+		if red.MinCut != c.expMinCut {
+			t.Errorf("%s to %s: expected min cut %d but got %d", c.from, c.to, c.expMinCut, red.MinCut)
+		}
+		var got []string
+		for _, b := range red.Bottlenecks {
+			got = append(got, b[0].Name+"–"+b[1].Name)
+		}
+		if len(got) != len(c.expBottlenecks) {
+			t.Errorf("%s to %s: expected bottlenecks %v but got %v", c.from, c.to, c.expBottlenecks, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.expBottlenecks[i] {
+				t.Errorf("%s to %s: expected bottlenecks %v but got %v", c.from, c.to, c.expBottlenecks, got)
+				break
+			}
+		}
+	}
+}
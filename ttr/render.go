@@ -0,0 +1,206 @@
+package ttr
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+)
+
+// SVG colors for route colors. Gray routes are "wild" because any color of
+// train card may claim them.
+var svgRouteColors = map[string]string{
+	"wild":   "silver",
+	"dark":   "black",
+	"red":    "red",
+	"orange": "orange",
+	"yellow": "gold",
+	"green":  "green",
+	"blue":   "blue",
+	"pink":   "hotpink",
+	"white":  "white",
+}
+
+func svgRouteColor(color string) string {
+	if c, ok := svgRouteColors[color]; ok {
+		return c
+	}
+	return "purple" // stands out as unknown
+}
+
+// A map projection scales and translates city coordinates so that all cities
+// fit within a box, preserving the map's aspect ratio.
+type mapProjection struct {
+	minX  float64
+	minY  float64
+	scale float64
+	left  float64
+	top   float64
+}
+
+// Returns a projection into the box with its top-left corner at (x, y). The
+// universe must be located.
+func newMapProjection(u *Univ, x, y, width, height float64) mapProjection {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, c := range u.cityByName {
+		minX = math.Min(minX, c.x)
+		minY = math.Min(minY, c.y)
+		maxX = math.Max(maxX, c.x)
+		maxY = math.Max(maxY, c.y)
+	}
+	pr := mapProjection{minX: minX, minY: minY, scale: 1}
+	if maxX > minX {
+		pr.scale = width / (maxX - minX)
+	}
+	if maxY > minY {
+		pr.scale = math.Min(pr.scale, height/(maxY-minY))
+	}
+	pr.left = x + (width-(maxX-minX)*pr.scale)/2
+	pr.top = y + (height-(maxY-minY)*pr.scale)/2
+	return pr
+}
+
+func (pr mapProjection) point(c *City) (x, y float64) {
+	return pr.left + (c.x-pr.minX)*pr.scale, pr.top + (c.y-pr.minY)*pr.scale
+}
+
+// Dimensions of a rendered map, in millimeters. The page is A4 landscape.
+const (
+	mapPageWidth  = 297.0
+	mapPageHeight = 210.0
+	mapMargin     = 15.0
+	mapCityRadius = 1.8
+)
+
+// Writes the whole map as an SVG page. Each route is drawn as a row of train
+// spaces in the route's color, and double routes are drawn side by side. The
+// universe must be located.
+func WriteMapSVG(w io.Writer, u *Univ) error {
+	var buf bytes.Buffer
+	svgStart(&buf, mapPageWidth, mapPageHeight)
+	pr := newMapProjection(u, mapMargin, mapMargin, mapPageWidth-2*mapMargin, mapPageHeight-2*mapMargin)
+	for _, l := range u.allLinks() {
+		writeLinkRoutes(&buf, pr, l)
+	}
+	writeMapCities(&buf, pr, u)
+	svgEnd(&buf)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// Writes the whole map as an SVG page, as WriteMapSVG does, with each link
+// that destinations' paths cross overlaid by a band whose color, from yellow to
+// red, and width show how many paths cross it, and labeled with that number.
+// The universe must be located.
+func WriteUsageSVG(w io.Writer, u *Univ, usage []LinkUsage) error {
+	var buf bytes.Buffer
+	svgStart(&buf, mapPageWidth, mapPageHeight)
+	pr := newMapProjection(u, mapMargin, mapMargin, mapPageWidth-2*mapMargin, mapPageHeight-2*mapMargin)
+	for _, l := range u.allLinks() {
+		writeLinkRoutes(&buf, pr, l)
+	}
+	maxCount := 0
+	for _, lu := range usage {
+		if lu.Count > maxCount {
+			maxCount = lu.Count
+		}
+	}
+	for _, lu := range usage {
+		if lu.Count == 0 {
+			continue
+		}
+		heat := float64(lu.Count) / float64(maxCount)
+		x1, y1 := pr.point(lu.City1)
+		x2, y2 := pr.point(lu.City2)
+		style := fmt.Sprintf("stroke:rgb(255,%d,0);stroke-width:%s;stroke-opacity:0.6;stroke-linecap:round",
+			int(220*(1-heat)), svgNum(1.5+4*heat))
+		svgLine(&buf, x1, y1, x2, y2, style)
+		svgText(&buf, (x1+x2)/2, (y1+y2)/2+1, 3, "font-family:sans-serif;font-weight:bold;fill:black",
+			strconv.Itoa(lu.Count))
+	}
+	writeMapCities(&buf, pr, u)
+	svgEnd(&buf)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+func writeLinkRoutes(buf *bytes.Buffer, pr mapProjection, l link) {
+	const spacing = 1.6 // between parallel routes
+	const gap = 0.6     // between train spaces
+	routes := l.city1.routes[l.city2]
+	for i, r := range routes {
+		offset := (float64(i) - float64(len(routes)-1)/2) * spacing
+
+		// Tunnels have a heavier outline, and ferries have a dot on each train
+		// space that requires a locomotive.
+		outline := "stroke:black;stroke-width:1.4"
+		if r.Tunnel {
+			outline = "stroke:black;stroke-width:1.9"
+		}
+		space := 0
+		forEachTrainSpace(pr, l, r.Dist, offset, gap, func(x1, y1, x2, y2 float64) {
+			svgLine(buf, x1, y1, x2, y2, outline)
+			svgLine(buf, x1, y1, x2, y2, "stroke:"+svgRouteColor(r.Color)+";stroke-width:1")
+			if space < r.Ferries {
+				svgCircle(buf, (x1+x2)/2, (y1+y2)/2, 0.3, "fill:black")
+			}
+			space++
+		})
+	}
+}
+
+// Divides the line between the two cities of a link, shifted sideways by the
+// given offset, into n train spaces separated by gaps. Calls f with the end
+// points of each train space.
+func forEachTrainSpace(pr mapProjection, l link, n int, offset, gap float64, f func(x1, y1, x2, y2 float64)) {
+	x1, y1 := pr.point(l.city1)
+	x2, y2 := pr.point(l.city2)
+	length := math.Hypot(x2-x1, y2-y1)
+	if length == 0 || n <= 0 {
+		return
+	}
+	dx, dy := (x2-x1)/length, (y2-y1)/length
+	x1, y1 = x1-dy*offset, y1+dx*offset
+
+	// leave room for the city markers at either end:
+	usable := length - 2*mapCityRadius
+	spaceLen := (usable - gap*float64(n+1)) / float64(n)
+	if spaceLen <= 0 {
+		spaceLen = usable / float64(n)
+		gap = 0
+	}
+	for i := 0; i < n; i++ {
+		start := mapCityRadius + gap + float64(i)*(spaceLen+gap)
+		end := start + spaceLen
+		f(x1+dx*start, y1+dy*start, x1+dx*end, y1+dy*end)
+	}
+}
+
+func writeMapCities(buf *bytes.Buffer, pr mapProjection, u *Univ) {
+	for _, c := range u.Cities() {
+		x, y := pr.point(c)
+		svgCircle(buf, x, y, mapCityRadius, "fill:white;stroke:black;stroke-width:0.4")
+		svgText(buf, x, y-mapCityRadius-0.8, 3, "font-family:sans-serif;fill:black", c.Name)
+	}
+}
+
+// Draws a small map, without labels or route colors, in the box with its
+// top-left corner at (x, y), with the given cities highlighted.
+func writeMiniMap(buf *bytes.Buffer, u *Univ, x, y, width, height float64, highlight ...*City) {
+	pr := newMapProjection(u, x, y, width, height)
+	for _, l := range u.allLinks() {
+		x1, y1 := pr.point(l.city1)
+		x2, y2 := pr.point(l.city2)
+		svgLine(buf, x1, y1, x2, y2, "stroke:silver;stroke-width:0.2")
+	}
+	for _, c := range u.Cities() {
+		cx, cy := pr.point(c)
+		svgCircle(buf, cx, cy, 0.35, "fill:gray")
+	}
+	for _, c := range highlight {
+		cx, cy := pr.point(c)
+		svgCircle(buf, cx, cy, 1, "fill:red;stroke:black;stroke-width:0.2")
+	}
+}
@@ -0,0 +1,120 @@
+package ttr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"math"
+	"testing"
+)
+
+func newLocatedUniv(t *testing.T) *Univ {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 3 red, 3 blue
+		bravo - charlie: 2 wild
+	`))
+	err := u.setCoords([]coordEnt{
+		{"alpha", 0, 0},
+		{"bravo", 10, 0},
+		{"charlie", 10, 5},
+	})
+	if err != nil {
+		t.Fatalf("got error setting coordinates: %s", err)
+	}
+	return u
+}
+
+func TestNewMapProjection(t *testing.T) {
+	u := newLocatedUniv(t)
+
+	// The map is twice as wide as it is tall, so in a square box it's centered
+	// vertically.
+	pr := newMapProjection(u, 100, 200, 20, 20)
+	check := func(name string, expX, expY float64) {
+		x, y := pr.point(u.cityByName[name])
+		if math.Abs(x-expX) > 1e-9 || math.Abs(y-expY) > 1e-9 {
+			t.Errorf("expected %q at (%v, %v) but got (%v, %v)", name, expX, expY, x, y)
+		}
+	}
+	check("alpha", 100, 205)
+	check("bravo", 120, 205)
+	check("charlie", 120, 215)
+}
+
+func TestForEachTrainSpace(t *testing.T) {
+	u := newLocatedUniv(t)
+	pr := newMapProjection(u, 0, 0, 100, 50)
+	l := newLink(u.cityByName["alpha"], u.cityByName["bravo"])
+
+	var spaces [][4]float64
+	forEachTrainSpace(pr, l, 3, 1, 1, func(x1, y1, x2, y2 float64) {
+		spaces = append(spaces, [4]float64{x1, y1, x2, y2})
+	})
+	if len(spaces) != 3 {
+		t.Fatalf("expected 3 train spaces but got %d", len(spaces))
+	}
+	for i, s := range spaces {
+		if s[1] != 1 || s[3] != 1 {
+			t.Errorf("train space %d isn't offset: %v", i, s)
+		}
+		if s[0] < mapCityRadius || s[2] > 100-mapCityRadius {
+			t.Errorf("train space %d overlaps a city: %v", i, s)
+		}
+		if i > 0 && s[0] <= spaces[i-1][2] {
+			t.Errorf("train space %d overlaps train space %d: %v, %v", i, i-1, s, spaces[i-1])
+		}
+	}
+}
+
+func TestWriteMapSVG(t *testing.T) {
+	u := newLocatedUniv(t)
+	var buf bytes.Buffer
+	if err := WriteMapSVG(&buf, u); err != nil {
+		t.Fatalf("got error writing map: %s", err)
+	}
+
+	// check: output is well-formed XML with one element per train space, per
+	// city, and per label
+	counts := countSVGElements(t, buf.Bytes())
+	if counts["line"] != 2*(3+3+2) || counts["circle"] != 3 || counts["text"] != 3 {
+		t.Errorf("got unexpected element counts %v", counts)
+	}
+}
+
+func TestWriteUsageSVG(t *testing.T) {
+	u := newLocatedUniv(t)
+	dests := []*Dest{newDest(u.cityByName["alpha"], u.cityByName["bravo"], 3)}
+	var buf bytes.Buffer
+	usage, err := CountLinkUsage(u, dests, false)
+	if err != nil {
+		t.Fatalf("got error counting usage: %s", err)
+	}
+	if err := WriteUsageSVG(&buf, u, usage); err != nil {
+		t.Fatalf("got error writing map: %s", err)
+	}
+
+	// check: same as the plain map plus one band and one label for the only
+	// used link
+	counts := countSVGElements(t, buf.Bytes())
+	if counts["line"] != 2*(3+3+2)+1 || counts["circle"] != 3 || counts["text"] != 3+1 {
+		t.Errorf("got unexpected element counts %v", counts)
+	}
+}
+
+// Parses an SVG document and returns the number of elements of each name.
+func countSVGElements(t *testing.T, data []byte) map[string]int {
+	counts := make(map[string]int)
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatalf("got error parsing SVG: %s", err)
+		}
+		if se, ok := tok.(xml.StartElement); ok {
+			counts[se.Name.Local]++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,177 @@
+package ttr
+
+import (
+	"fmt"
+	"sort"
+)
+
+// A scorer determines the point value of a destination between two cities.
+type Scorer interface {
+	Score(c1, c2 *City) int
+}
+
+// Names of the built-in scorers, as accepted by NewScorer.
+var ScorerNames = []string{"fewest-hops", "shortest", "official", "bottleneck", "hazard", "redundancy", "colors"}
+
+// Returns the built-in scorer with the given name. The official destinations
+// are needed only by the "official" scorer, and the universe and number of
+// players only by the "bottleneck" and "redundancy" scorers.
+func NewScorer(name string, official []*Dest, u *Univ, players int) (Scorer, error) {
+	switch name {
+	case "fewest-hops":
+		return fewestHopsScorer{}, nil
+	case "shortest":
+		return shortestDistScorer{}, nil
+	case "official":
+		if len(official) == 0 {
+			return nil, fmt.Errorf("scorer %q requires official destinations", name)
+		}
+		return newOfficialScorer(official), nil
+	case "bottleneck":
+		return bottleneckScorer{u: u, players: players}, nil
+	case "hazard":
+		return hazardScorer{}, nil
+	case "redundancy":
+		return redundancyScorer{u: u, players: players}, nil
+	case "colors":
+		return colorsScorer{}, nil
+	}
+	return nil, fmt.Errorf("invalid scorer %q", name)
+}
+
+// Scores a destination as the distance of its fewest-hops path.
+type fewestHopsScorer struct{}
+
+func (fewestHopsScorer) Score(c1, c2 *City) int {
+	return c1.fewestHops[c2].Dist
+}
+
+// Scores a destination as the distance of its shortest path.
+type shortestDistScorer struct{}
+
+func (shortestDistScorer) Score(c1, c2 *City) int {
+	return c1.shortestDist[c2].Dist
+}
+
+// Scores a destination by looking up its shortest distance in a table of
+// points per distance learned from the official destinations. Distances
+// missing from the table are interpolated from the nearest known distances.
+type officialScorer struct {
+	dists  []int // sorted
+	points []float64
+}
+
+func newOfficialScorer(official []*Dest) *officialScorer {
+	sums := make(map[int]int)
+	counts := make(map[int]int)
+	for _, d := range official {
+		dist := d.City1.shortestDist[d.City2].Dist
+		sums[dist] += d.Value
+		counts[dist]++
+	}
+	sc := new(officialScorer)
+	for dist := range sums {
+		sc.dists = append(sc.dists, dist)
+	}
+	sort.Ints(sc.dists)
+	for _, dist := range sc.dists {
+		sc.points = append(sc.points, float64(sums[dist])/float64(counts[dist]))
+	}
+	return sc
+}
+
+func (sc *officialScorer) Score(c1, c2 *City) int {
+	return round(sc.lookup(c1.shortestDist[c2].Dist))
+}
+
+func (sc *officialScorer) lookup(dist int) float64 {
+	i := sort.SearchInts(sc.dists, dist)
+	switch {
+	case i < len(sc.dists) && sc.dists[i] == dist:
+		return sc.points[i]
+	case i == 0:
+		// below the table: scale the smallest entry
+		return sc.points[0] * float64(dist) / float64(sc.dists[0])
+	case i == len(sc.dists):
+		// above the table: scale the largest entry
+		n := len(sc.dists) - 1
+		return sc.points[n] * float64(dist) / float64(sc.dists[n])
+	}
+	lo, hi := i-1, i
+	frac := float64(dist-sc.dists[lo]) / float64(sc.dists[hi]-sc.dists[lo])
+	return sc.points[lo] + frac*(sc.points[hi]-sc.points[lo])
+}
+
+func round(x float64) int {
+	return int(x + 0.5)
+}
+
+// Scores a destination as the distance of its shortest path plus one point for
+// each bottleneck along that path. A bottleneck is a pair of adjacent cities
+// connected by only one usable route, which a single opponent can block. In
+// games with few players, double routes count as bottlenecks.
+type bottleneckScorer struct {
+	u       *Univ
+	players int
+}
+
+func (sc bottleneckScorer) Score(c1, c2 *City) int {
+	p := c1.shortestDist[c2]
+	value := p.Dist
+	for i := 1; i < len(p.Cities); i++ {
+		if len(sc.u.UsableRoutes(p.Cities[i-1], p.Cities[i], sc.players)) == 1 {
+			value++
+		}
+	}
+	return value
+}
+
+// Scores a destination as the distance of its shortest path plus one point for
+// each hazard along that path: one for each tunnel and one for each locomotive
+// that a ferry requires. Among equally short paths, the shortest path has the
+// fewest hazards, so only destinations that can't avoid hazards are penalized.
+type hazardScorer struct{}
+
+func (hazardScorer) Score(c1, c2 *City) int {
+	p := c1.shortestDist[c2]
+	return p.Dist + p.Hazards
+}
+
+// The minimum cut at or above which a destination is considered hard to block.
+const redundancyTarget = 3
+
+// Scores a destination as the distance of its shortest path plus one point for
+// each route by which its minimum cut falls short of redundancyTarget, and one
+// point for each bottleneck link that every path must cross. A destination that
+// one or two claimed routes can block is worth more than its length suggests.
+type redundancyScorer struct {
+	u       *Univ
+	players int
+}
+
+func (sc redundancyScorer) Score(c1, c2 *City) int {
+	red := sc.u.Redundancy(c1, c2, sc.players)
+	value := c1.shortestDist[c2].Dist + len(red.Bottlenecks)
+	if red.MinCut < redundancyTarget {
+		value += redundancyTarget - red.MinCut
+	}
+	return value
+}
+
+// Scores a destination as the distance of its shortest path plus one point for
+// each distinct color of train card, beyond the first, that the path needs.
+// Among equally short paths, the one needing the fewest colors counts, and gray
+// routes need no particular color. Cities that aren't connected score zero.
+type colorsScorer struct{}
+
+func (colorsScorer) Score(c1, c2 *City) int {
+	p := fewestColorsPath(c1, c2)
+	if p == nil {
+		return 0
+	}
+	value := p.Dist
+	if n := p.NumCardColors(); n > 1 {
+		value += n - 1
+	}
+	return value
+}
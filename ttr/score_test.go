@@ -0,0 +1,113 @@
+package ttr
+
+import (
+	"testing"
+)
+
+func TestNewScorer(t *testing.T) {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString("alpha - bravo: 1 wild\n"))
+	official := []*Dest{newDest(u.cityByName["alpha"], u.cityByName["bravo"], 1)}
+	for _, name := range ScorerNames {
+		if _, err := NewScorer(name, official, u, 4); err != nil {
+			t.Errorf("got error creating scorer %q: %s", name, err)
+		}
+	}
+	if _, err := NewScorer("official", nil, u, 4); err == nil {
+		t.Errorf("expected error creating official scorer without official destinations but got none")
+	}
+	if _, err := NewScorer("bogus", nil, u, 4); err == nil {
+		t.Errorf("expected error creating unknown scorer but got none")
+	}
+}
+
+func TestScorers(t *testing.T) {
+
+	// The fewest-hops path from alpha to delta is the direct route, length 6;
+	// the shortest path is via bravo and charlie, length 3, and the link between
+	// bravo and charlie is a bottleneck.
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 1 wild, 1 wild
+		bravo - charlie: 1 red
+		charlie - delta: 1 blue, 1 green
+		alpha - delta: 6 wild
+	`))
+	alpha := u.cityByName["alpha"]
+	delta := u.cityByName["delta"]
+
+	check := func(sc Scorer, exp int) {
+		if got := sc.Score(alpha, delta); got != exp {
+			t.Errorf("with %T, expected %d but got %d", sc, exp, got)
+		}
+	}
+	check(fewestHopsScorer{}, 6)
+	check(shortestDistScorer{}, 3)
+	check(bottleneckScorer{u: u, players: 4}, 4)
+	check(bottleneckScorer{u: u, players: 2}, 6)
+	check(hazardScorer{}, 3)
+	check(redundancyScorer{u: u, players: 4}, 4)
+	check(colorsScorer{}, 4)
+}
+
+func TestHazardScorer(t *testing.T) {
+
+	// Both paths from alpha to charlie have length 4, but only the one via bravo
+	// avoids hazards. The only path to delta is a ferry requiring two
+	// locomotives.
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 2 wild
+		bravo - charlie: 2 wild
+		alpha - charlie: 4 wild tunnel
+		charlie - delta: 2 wild ferry=2
+	`))
+	alpha := u.cityByName["alpha"]
+	check := func(tgtName string, exp int) {
+		if got := (hazardScorer{}).Score(alpha, u.cityByName[tgtName]); got != exp {
+			t.Errorf("to %q, expected %d but got %d", tgtName, exp, got)
+		}
+	}
+	check("charlie", 4)
+	check("delta", 8)
+}
+
+func TestOfficialScorerLookup(t *testing.T) {
+	sc := &officialScorer{
+		dists:  []int{4, 8, 10},
+		points: []float64{5, 9, 15},
+	}
+	check := func(dist int, exp float64) {
+		if got := sc.lookup(dist); got != exp {
+			t.Errorf("with distance %d, expected %v but got %v", dist, exp, got)
+		}
+	}
+	check(2, 2.5)
+	check(4, 5)
+	check(6, 7)
+	check(8, 9)
+	check(9, 12)
+	check(10, 15)
+	check(20, 30)
+}
+
+func TestNewOfficialScorer(t *testing.T) {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 2 wild
+		bravo - charlie: 2 wild
+	`))
+	alpha := u.cityByName["alpha"]
+	bravo := u.cityByName["bravo"]
+	charlie := u.cityByName["charlie"]
+	sc := newOfficialScorer([]*Dest{
+		newDest(alpha, bravo, 3),
+		newDest(bravo, charlie, 4),
+		newDest(alpha, charlie, 9),
+	})
+	if len(sc.dists) != 2 || sc.dists[0] != 2 || sc.dists[1] != 4 {
+		t.Fatalf("got unexpected distances %v", sc.dists)
+	}
+	if sc.points[0] != 3.5 || sc.points[1] != 9 {
+		t.Errorf("got unexpected points %v", sc.points)
+	}
+	if got := sc.Score(alpha, charlie); got != 9 {
+		t.Errorf("expected 9 but got %d", got)
+	}
+}
@@ -0,0 +1,297 @@
+package ttr
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// Points for claiming a route, indexed by the route's length.
+var routePoints = []int{0, 1, 2, 4, 7, 10, 15, 18, 21}
+
+// Returns the points for claiming a route of the given length.
+func RoutePoints(length int) int {
+	if length < 0 || length >= len(routePoints) {
+		return 0
+	}
+	return routePoints[length]
+}
+
+// Options for Simulate.
+type SimulateOptions struct {
+	Games   int   // number of games to play
+	Players int   // default: 4
+	Seed    int64 // for the random-number generator
+
+	Tickets int // destinations dealt to each player; default: 3
+	Trains  int // trains each player starts with; default: 45
+}
+
+// How a destination fared over a number of simulated games.
+type TicketStats struct {
+	Dest      *Dest
+	Dealt     int // games in which a player held the destination
+	Completed int // games in which that player connected the cities
+	Points    int // total points the destination scored: its value if completed, less its value if not
+}
+
+// Returns the fraction of games in which the destination was completed, of
+// those in which it was dealt.
+func (ts *TicketStats) CompletionRate() float64 {
+	if ts.Dealt == 0 {
+		return 0
+	}
+	return float64(ts.Completed) / float64(ts.Dealt)
+}
+
+// Returns the average points the destination scored when dealt.
+func (ts *TicketStats) AvgPoints() float64 {
+	if ts.Dealt == 0 {
+		return 0
+	}
+	return float64(ts.Points) / float64(ts.Dealt)
+}
+
+// The outcome of Simulate.
+type Simulation struct {
+	Games    int
+	Tickets  []*TicketStats // one per destination, in deck order
+	AvgScore float64        // average final score of a player
+}
+
+// Plays games of Ticket to Ride between simple bots and tallies how often each
+// destination is completed. In each game, every player is dealt destinations
+// from the shuffled deck and then, turn by turn, claims one route toward the
+// held destination that is nearest to completion, or, with none left in
+// reach, the longest route it can afford. The game ends, as usual, one round
+// after a player is down to two trains, or once nobody can claim anything.
+//
+// The bots don't draw train cards: any route a player has the trains for is
+// theirs to claim. Both routes of a double route are usable only in games
+// with enough players, and no player may claim both. The result depends only
+// on the universe, the destinations, and the options.
+func Simulate(u *Univ, dests []*Dest, opts SimulateOptions) (*Simulation, error) {
+	if opts.Players <= 0 {
+		opts.Players = 4
+	}
+	if opts.Tickets <= 0 {
+		opts.Tickets = 3
+	}
+	if opts.Trains <= 0 {
+		opts.Trains = 45
+	}
+	if opts.Games <= 0 {
+		return nil, fmt.Errorf("invalid number of games %d", opts.Games)
+	}
+	if n := opts.Players * opts.Tickets; n > len(dests) {
+		return nil, fmt.Errorf("%d players need %d destinations but the deck has only %d", opts.Players, n, len(dests))
+	}
+
+	sim := &Simulation{Games: opts.Games}
+	for _, d := range dests {
+		sim.Tickets = append(sim.Tickets, &TicketStats{Dest: d})
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	totalScore := 0
+	for i := 0; i < opts.Games; i++ {
+		g := newGame(u, opts)
+		deck := rng.Perm(len(dests))
+		for p := range g.players {
+			g.players[p].tickets = deck[p*opts.Tickets : (p+1)*opts.Tickets]
+		}
+		g.play(dests)
+		for _, pl := range g.players {
+			for _, j := range pl.tickets {
+				ts := sim.Tickets[j]
+				ts.Dealt++
+				if pl.connects(dests[j].City1, dests[j].City2) {
+					ts.Completed++
+					ts.Points += dests[j].Value
+				} else {
+					ts.Points -= dests[j].Value
+				}
+			}
+			totalScore += pl.score(dests)
+		}
+	}
+	sim.AvgScore = float64(totalScore) / float64(opts.Games*opts.Players)
+	return sim, nil
+}
+
+// A simulated game in progress.
+type game struct {
+	u       *Univ
+	cities  []*City
+	players []*player
+	owner   map[*Route]*player
+}
+
+type player struct {
+	trains  int
+	tickets []int // indexes into the deck
+	routes  map[link]*Route
+}
+
+func newGame(u *Univ, opts SimulateOptions) *game {
+	g := &game{u: u, cities: u.Cities(), owner: make(map[*Route]*player)}
+	for i := 0; i < opts.Players; i++ {
+		g.players = append(g.players, &player{trains: opts.Trains, routes: make(map[link]*Route)})
+	}
+	return g
+}
+
+// Takes turns until the game ends.
+func (g *game) play(dests []*Dest) {
+	lastTurns := -1 // turns left once a player runs low on trains
+	passes := 0     // consecutive turns in which the player couldn't claim a route
+	for turn := 0; lastTurns != 0 && passes < len(g.players); turn++ {
+		pl := g.players[turn%len(g.players)]
+		if g.takeTurn(pl, dests) {
+			passes = 0
+		} else {
+			passes++
+		}
+		if lastTurns > 0 {
+			lastTurns--
+		} else if pl.trains <= 2 {
+			lastTurns = len(g.players)
+		}
+	}
+}
+
+// Claims one route for the player, returning false if there's none the player
+// can claim.
+func (g *game) takeTurn(pl *player, dests []*Dest) bool {
+	var best []hop
+	bestCost := 0
+	for _, j := range pl.tickets {
+		d := dests[j]
+		hops, cost := g.cheapestPath(pl, d.City1, d.City2)
+		if hops == nil || cost == 0 || cost > pl.trains {
+			continue // unreachable, already complete, or unaffordable
+		}
+		if best == nil || cost < bestCost {
+			best, bestCost = hops, cost
+		}
+	}
+	for _, h := range best {
+		if h.route != nil {
+			g.claim(pl, h)
+			return true
+		}
+	}
+
+	// With no destination in reach, claim the longest route for its points.
+	var longest *hop
+	for _, c := range g.cities {
+		for _, adj := range c.adj {
+			if c.Name > adj.Name {
+				continue
+			}
+			for _, r := range g.u.UsableRoutes(c, adj, len(g.players)) {
+				if g.canClaim(pl, c, adj, r) && (longest == nil || r.Dist > longest.route.Dist) {
+					longest = &hop{c, adj, r}
+				}
+			}
+		}
+	}
+	if longest == nil {
+		return false
+	}
+	g.claim(pl, *longest)
+	return true
+}
+
+// One hop of a player's path, with the route to claim, or nil if the player
+// already owns a route between the cities.
+type hop struct {
+	from, to *City
+	route    *Route
+}
+
+func (g *game) claim(pl *player, h hop) {
+	g.owner[h.route] = pl
+	pl.routes[newLink(h.from, h.to)] = h.route
+	pl.trains -= h.route.Dist
+}
+
+// Returns whether the player may claim a usable route between two adjacent
+// cities: it's unclaimed, the player has the trains for it, and the player
+// doesn't own the other route of a double route.
+func (g *game) canClaim(pl *player, c1, c2 *City, r *Route) bool {
+	return g.owner[r] == nil && r.Dist <= pl.trains && pl.routes[newLink(c1, c2)] == nil
+}
+
+// Returns the hops of the path between two cities that needs the fewest more
+// trains from the player, counting routes the player owns as free, and the
+// trains it needs. It returns nil if opponents have blocked every path.
+func (g *game) cheapestPath(pl *player, src, dst *City) ([]hop, int) {
+	// Dijkstra's algorithm, scanning for the nearest city, which is quick enough
+	// for a game board's few dozen cities.
+	cost := map[*City]int{src: 0}
+	prev := make(map[*City]hop)
+	done := make(map[*City]bool)
+	for {
+		var cur *City
+		for _, c := range g.cities {
+			if _, ok := cost[c]; ok && !done[c] && (cur == nil || cost[c] < cost[cur]) {
+				cur = c
+			}
+		}
+		if cur == nil {
+			return nil, 0
+		}
+		if cur == dst {
+			break
+		}
+		done[cur] = true
+		for _, adj := range cur.adj {
+			if done[adj] {
+				continue
+			}
+			h := hop{from: cur, to: adj}
+			if pl.routes[newLink(cur, adj)] == nil {
+				for _, r := range g.u.UsableRoutes(cur, adj, len(g.players)) {
+					if g.canClaim(pl, cur, adj, r) && (h.route == nil || r.Dist < h.route.Dist) {
+						h.route = r
+					}
+				}
+				if h.route == nil {
+					continue
+				}
+			}
+			c := cost[cur]
+			if h.route != nil {
+				c += h.route.Dist
+			}
+			if old, ok := cost[adj]; !ok || c < old {
+				cost[adj] = c
+				prev[adj] = h
+			}
+		}
+	}
+	var hops []hop
+	for c := dst; c != src; c = prev[c].from {
+		hops = append([]hop{prev[c]}, hops...)
+	}
+	return hops, cost[dst]
+}
+
+// Returns whether the player's routes connect two cities.
+func (pl *player) connects(c1, c2 *City) bool {
+	return connects(c1, c2, func(a, b *City) bool { return pl.routes[newLink(a, b)] != nil })
+}
+
+// Returns the player's score from routes and destinations.
+func (pl *player) score(dests []*Dest) (n int) {
+	for _, r := range pl.routes {
+		n += RoutePoints(r.Dist)
+	}
+	for _, j := range pl.tickets {
+		if d := dests[j]; pl.connects(d.City1, d.City2) {
+			n += d.Value
+		} else {
+			n -= d.Value
+		}
+	}
+	return
+}
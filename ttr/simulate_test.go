@@ -0,0 +1,49 @@
+package ttr
+
+import (
+	"testing"
+)
+
+func TestSimulate(t *testing.T) {
+
+	// Whoever holds bravo–charlie claims its only route before the holder of
+	// alpha–charlie can get there, which blocks alpha–charlie.
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 1 wild
+		bravo - charlie: 1 wild
+	`))
+	alpha := u.cityByName["alpha"]
+	bravo := u.cityByName["bravo"]
+	charlie := u.cityByName["charlie"]
+	dests := []*Dest{newDest(alpha, charlie, 2), newDest(bravo, charlie, 1)}
+
+	sim, err := Simulate(u, dests, SimulateOptions{Games: 10, Players: 2, Tickets: 1, Seed: 1})
+	if err != nil {
+		t.Fatalf("got error: %s", err)
+	}
+	long, short := sim.Tickets[0], sim.Tickets[1]
+	if long.Dealt != 10 || long.Completed != 0 || long.AvgPoints() != -2 {
+		t.Errorf("expected alpha–charlie to fail every game but got %+v", *long)
+	}
+	if short.Dealt != 10 || short.CompletionRate() != 1 || short.AvgPoints() != 1 {
+		t.Errorf("expected bravo–charlie to succeed every game but got %+v", *short)
+	}
+
+	// Each player claims one route, worth a point, and then one scores 1 for a
+	// destination and the other loses 2.
+	if exp := (1 + 1 + 1 - 2) / 2.0; sim.AvgScore != exp {
+		t.Errorf("expected average score %v but got %v", exp, sim.AvgScore)
+	}
+
+	if _, err := Simulate(u, dests, SimulateOptions{Games: 1, Players: 3, Tickets: 1}); err == nil {
+		t.Errorf("expected error for too few destinations but got none")
+	}
+}
+
+func TestRoutePoints(t *testing.T) {
+	for length, exp := range []int{0, 1, 2, 4, 7, 10, 15, 18, 21} {
+		if got := RoutePoints(length); got != exp {
+			t.Errorf("expected %d points for length %d but got %d", exp, length, got)
+		}
+	}
+}
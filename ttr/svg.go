@@ -0,0 +1,53 @@
+package ttr
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// Helpers for writing SVG documents. All drawing is done in millimeters so
+// that printed output comes out at the intended size.
+
+func svgEscape(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func svgStart(buf *bytes.Buffer, width, height float64) {
+	fmt.Fprintf(buf, "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+	fmt.Fprintf(buf, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%smm\" height=\"%smm\" viewBox=\"0 0 %s %s\">\n",
+		svgNum(width), svgNum(height), svgNum(width), svgNum(height))
+}
+
+func svgEnd(buf *bytes.Buffer) {
+	fmt.Fprintf(buf, "</svg>\n")
+}
+
+// Formats a coordinate or length without excess digits.
+func svgNum(x float64) string {
+	return strconv.FormatFloat(math.Round(x*100)/100, 'f', -1, 64)
+}
+
+func svgLine(buf *bytes.Buffer, x1, y1, x2, y2 float64, style string) {
+	fmt.Fprintf(buf, "<line x1=\"%s\" y1=\"%s\" x2=\"%s\" y2=\"%s\" style=\"%s\"/>\n", svgNum(x1), svgNum(y1),
+		svgNum(x2), svgNum(y2), style)
+}
+
+func svgRect(buf *bytes.Buffer, x, y, width, height, radius float64, style string) {
+	fmt.Fprintf(buf, "<rect x=\"%s\" y=\"%s\" width=\"%s\" height=\"%s\" rx=\"%s\" style=\"%s\"/>\n", svgNum(x),
+		svgNum(y), svgNum(width), svgNum(height), svgNum(radius), style)
+}
+
+func svgCircle(buf *bytes.Buffer, cx, cy, r float64, style string) {
+	fmt.Fprintf(buf, "<circle cx=\"%s\" cy=\"%s\" r=\"%s\" style=\"%s\"/>\n", svgNum(cx), svgNum(cy), svgNum(r), style)
+}
+
+// Writes text centered horizontally at x, with its baseline at y.
+func svgText(buf *bytes.Buffer, x, y, size float64, style, text string) {
+	fmt.Fprintf(buf, "<text x=\"%s\" y=\"%s\" font-size=\"%s\" text-anchor=\"middle\" style=\"%s\">%s</text>\n",
+		svgNum(x), svgNum(y), svgNum(size), style, svgEscape(text))
+}
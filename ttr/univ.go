@@ -0,0 +1,540 @@
+package ttr
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// TERMINOLOGY
+//
+// Route: A single connecting path between two adjacent cities. A route
+// comprises a distance and color. On some maps, a route may also be a tunnel,
+// which may cost extra cards to claim, or a ferry, which requires a number of
+// locomotive cards to claim.
+//
+// Hop: A pair comprising a city and a route. The typical meaning of a hop is a
+// route that leads to the given city.
+//
+// Path: A path is a origin city plus a continuous sequence of hops connecting
+// two (possibly non-adjacent) cities. A path is (1) a sequence of N cities,
+// where N >= 1; (2) a sequence of N-1 routes, with the routes being the
+// connectors between the cities; and (3) the total distance of all routes.
+//
+// Destination: A pair of two cities connected by a path. A destination
+// comprises the two cities and a value measured in points.
+//
+
+type pathComparer func(*Path, *Path) int
+
+type City struct {
+	Name         string
+	routes       map[*City][]*Route
+	adj          []*City // the keys of routes, ordered alphabetically
+	fewestHops   map[*City]*Path
+	shortestDist map[*City]*Path
+
+	// position on the board, if known
+	located bool
+	x       float64
+	y       float64
+}
+
+func newCity(name string) *City {
+	return &City{
+		Name:         name,
+		routes:       make(map[*City][]*Route),
+		fewestHops:   make(map[*City]*Path),
+		shortestDist: make(map[*City]*Path),
+	}
+}
+
+func newCityMapFromRouteEntries(ents []routeEnt) (m map[string]*City) {
+	m = make(map[string]*City)
+	for _, ent := range ents {
+		// Step 1: Populate the map with both cities, creating an empty city for any
+		// that isn't already created.
+		c1 := m[ent.name1]
+		if c1 == nil {
+			c1 = newCity(ent.name1)
+			m[ent.name1] = c1
+		}
+		c2 := m[ent.name2]
+		if c2 == nil {
+			c2 = newCity(ent.name2)
+			m[ent.name2] = c2
+		}
+		// Step 2: Populate both cities with the route.
+		r := newRoute(ent.dist, ent.color)
+		r.Tunnel = ent.tunnel
+		r.Ferries = ent.ferries
+		if c1.routes[c2] == nil {
+			c1.adj = append(c1.adj, c2)
+			c2.adj = append(c2.adj, c1)
+		}
+		c1.routes[c2] = append(c1.routes[c2], r)
+		c2.routes[c1] = append(c2.routes[c1], r)
+	}
+	// Step 3: Order each city's adjacent cities so that everything that visits
+	// them, such as path finding, does so in the same order every time.
+	for _, c := range m {
+		sort.Slice(c.adj, func(i, j int) bool { return c.adj[i].Name < c.adj[j].Name })
+	}
+	return
+}
+
+// Compares paths by fewest hops, breaking ties by shortest distance and then by
+// fewest hazards.
+func compFewestHops(p1, p2 *Path) int {
+	return compInts(len(p1.Routes), len(p2.Routes), p1.Dist, p2.Dist, p1.Hazards, p2.Hazards)
+}
+
+// Compares paths by shortest distance, breaking ties by fewest hazards and then
+// by fewest hops.
+func compShortestDist(p1, p2 *Path) int {
+	return compInts(p1.Dist, p2.Dist, p1.Hazards, p2.Hazards, len(p1.Routes), len(p2.Routes))
+}
+
+// Compares pairs of integers lexicographically, with smaller being better.
+// Returns a positive number if the first of each pair is better, as per the
+// pathComparer convention.
+func compInts(pairs ...int) int {
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i] < pairs[i+1] {
+			return 1
+		} else if pairs[i] > pairs[i+1] {
+			return -1
+		}
+	}
+	return 0
+}
+
+func (c *City) populatePaths() {
+
+	// Find paths in parallel to speed things up.
+	var done sync.WaitGroup
+	goAndSignal := func(comp pathComparer, bestPaths map[*City]*Path) {
+		done.Add(1)
+		go func() {
+			c.findBestPaths(comp, bestPaths)
+			done.Done()
+		}()
+	}
+	goAndSignal(compFewestHops, c.fewestHops)
+	goAndSignal(compShortestDist, c.shortestDist)
+	done.Wait()
+}
+
+// Finds the "best" path from this city to every reachable city. The concept
+// of "best" is determined by a caller-supplied comparison function.
+//
+// The function comp returns a positive number if and only if its first path
+// argument is "better" than its second path argument, and it returns zero if
+// and only if the first path is "equally as good" as the second path.
+//
+// This is Dijkstra's algorithm, generalized to use the comparison function as
+// the priority. This works so long as extending a path by one hop never makes
+// it better, which holds for the lexicographic comparers (hops then distance,
+// distance then hops) used by this program.
+func (c *City) findBestPaths(comp pathComparer, bestPaths map[*City]*Path) {
+	pending := &pathHeap{comp: comp}
+	heap.Push(pending, newPath(c))
+	for pending.Len() > 0 {
+		p := heap.Pop(pending).(*Path)
+		curCity := p.Cities[len(p.Cities)-1]
+		if bestPaths[curCity] != nil {
+			continue // already found a path at least as good
+		}
+		bestPaths[curCity] = p
+		for _, adjCity := range curCity.adj {
+			if bestPaths[adjCity] != nil {
+				continue
+			}
+			for _, r := range curCity.routes[adjCity] {
+				next := copyPath(p)
+				next.appendHop(adjCity, r)
+				heap.Push(pending, next)
+			}
+		}
+	}
+}
+
+// A priority queue of paths, with the "best" path at the top, as determined by
+// a path comparer.
+type pathHeap struct {
+	comp  pathComparer
+	paths []*Path
+}
+
+func (h *pathHeap) Len() int           { return len(h.paths) }
+func (h *pathHeap) Less(i, j int) bool { return h.comp(h.paths[i], h.paths[j]) > 0 }
+func (h *pathHeap) Swap(i, j int)      { h.paths[i], h.paths[j] = h.paths[j], h.paths[i] }
+
+func (h *pathHeap) Push(x interface{}) {
+	h.paths = append(h.paths, x.(*Path))
+}
+
+func (h *pathHeap) Pop() interface{} {
+	n := len(h.paths)
+	p := h.paths[n-1]
+	h.paths[n-1] = nil
+	h.paths = h.paths[:n-1]
+	return p
+}
+
+type Path struct {
+	Cities  []*City
+	Routes  []*Route
+	Dist    int
+	Hazards int
+}
+
+func newPath(origCity *City) *Path {
+	return &Path{
+		Cities: []*City{origCity},
+	}
+}
+
+func copyPath(src *Path) *Path {
+	return &Path{
+		Cities:  append([]*City{}, src.Cities...),
+		Routes:  append([]*Route{}, src.Routes...),
+		Dist:    src.Dist,
+		Hazards: src.Hazards,
+	}
+}
+
+func (p *Path) appendHop(c *City, r *Route) {
+	p.Cities = append(p.Cities, c)
+	p.Routes = append(p.Routes, r)
+	p.Dist += r.Dist
+	p.Hazards += r.Hazards()
+}
+
+func (p *Path) chopHop() {
+	p.Dist -= p.Routes[len(p.Routes)-1].Dist
+	p.Hazards -= p.Routes[len(p.Routes)-1].Hazards()
+	p.Cities = p.Cities[:len(p.Cities)-1]
+	p.Routes = p.Routes[:len(p.Routes)-1]
+}
+
+func (p *Path) Equals(other *Path) bool {
+	if len(p.Cities) != len(other.Cities) {
+		return false
+	}
+	for i := 0; i < len(p.Cities); i++ {
+		if p.Cities[i] != other.Cities[i] {
+			return false
+		}
+	}
+	for i := 0; i < len(p.Routes); i++ {
+		if !p.Routes[i].Equals(other.Routes[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *Path) String() (s string) {
+	s = fmt.Sprintf("%q", p.Cities[0].Name)
+	if len(p.Cities)-1 != len(p.Routes) {
+		panic("invalid path")
+	}
+	for i, c := range p.Cities[1:] {
+		r := p.Routes[i]
+		s = fmt.Sprintf("%s–%s–%q", s, strings.Replace(r.String(), " ", ",", -1), c.Name)
+	}
+	return
+}
+
+// A link is the connection between two adjacent cities, regardless of how many
+// routes connect them. The two cities are in alphabetical order.
+type link struct {
+	city1 *City
+	city2 *City
+}
+
+func newLink(c1, c2 *City) link {
+	if c2.Name < c1.Name {
+		c1, c2 = c2, c1
+	}
+	return link{city1: c1, city2: c2}
+}
+
+// A number of train cards of one color.
+type ColorCount struct {
+	Color string `json:"color"`
+	Count int    `json:"count"`
+}
+
+// Returns the train cards needed to claim every route of the path, by color.
+// Gray routes need cards of any one color, counted as "wild", and a ferry's
+// locomotive spaces need locomotives, counted as "locomotive". Colors are in
+// the order of routeColors, then locomotives.
+func (p *Path) CardsNeeded() (cards []ColorCount) {
+	counts := make(map[string]int)
+	for _, r := range p.Routes {
+		counts[r.Color] += r.Dist - r.Ferries
+		counts["locomotive"] += r.Ferries
+	}
+	for _, color := range routeColors {
+		if counts[color] > 0 {
+			cards = append(cards, ColorCount{color, counts[color]})
+		}
+	}
+	if counts["locomotive"] > 0 {
+		cards = append(cards, ColorCount{"locomotive", counts["locomotive"]})
+	}
+	return
+}
+
+// Returns the set of links that a path traverses.
+func (p *Path) links() map[link]bool {
+	m := make(map[link]bool)
+	for i := 1; i < len(p.Cities); i++ {
+		m[newLink(p.Cities[i-1], p.Cities[i])] = true
+	}
+	return m
+}
+
+// Colors of routes. Gray routes are "wild" because any color of train card may
+// claim them, and black routes are "dark".
+var routeColors = []string{"wild", "dark", "red", "orange", "yellow", "green", "blue", "pink", "white"}
+
+func isRouteColor(color string) bool {
+	for _, c := range routeColors {
+		if c == color {
+			return true
+		}
+	}
+	return false
+}
+
+type Route struct {
+	Dist    int
+	Color   string
+	Tunnel  bool
+	Ferries int // number of locomotives required
+}
+
+func newRoute(dist int, color string) *Route {
+	return &Route{
+		Dist:  dist,
+		Color: color,
+	}
+}
+
+func (r *Route) Equals(other *Route) bool {
+	return r.Dist == other.Dist && r.Color == other.Color && r.Tunnel == other.Tunnel && r.Ferries == other.Ferries
+}
+
+// Returns how much harder than usual the route is to claim: one for a tunnel,
+// whose cost is uncertain, plus one for each locomotive that a ferry requires.
+func (r *Route) Hazards() int {
+	n := r.Ferries
+	if r.Tunnel {
+		n++
+	}
+	return n
+}
+
+// Returns the route in route-file format, e.g., "2 wild tunnel".
+func (r *Route) String() string {
+	s := fmt.Sprintf("%d %s", r.Dist, r.Color)
+	if r.Tunnel {
+		s += " tunnel"
+	}
+	if r.Ferries > 0 {
+		s += fmt.Sprintf(" ferry=%d", r.Ferries)
+	}
+	return s
+}
+
+// The standard rule for double routes is that both routes are usable only in
+// games with at least four players. In smaller games, once a player claims
+// one of the routes, the other is closed.
+const defaultDoubleRouteMinPlayers = 4
+
+// A universe is a game board's cities and routes, with the best paths between
+// every pair of cities already found. A universe is safe for concurrent use
+// once created, and the cities, paths, and routes it returns must not be
+// modified.
+type Univ struct {
+	Name       string
+	cityByName map[string]*City
+
+	// fewest players needed for both routes of a double route to be usable
+	doubleRouteMinPlayers int
+
+	stations int // per player, zero if the board has none
+}
+
+func newUnivFromRouteEntries(ents []routeEnt) (u *Univ) {
+	// TODO: test
+	u = new(Univ)
+	u.doubleRouteMinPlayers = defaultDoubleRouteMinPlayers
+	u.cityByName = newCityMapFromRouteEntries(ents)
+	for _, c := range u.cityByName {
+		c.populatePaths()
+	}
+	return
+}
+
+// Creates a universe from everything in a map, except for the destinations.
+// It's an error if some cities can't be reached from the others. Errors are
+// attributed to the map's file.
+func NewUniv(m *Map) (u *Univ, err error) {
+	// Path finding assumes that every route has a positive distance and joins
+	// two different cities.
+	for _, ent := range m.routeEnts {
+		if ent.dist <= 0 {
+			return nil, withFile(newParseError(ent.line, 0, "non-positive distance %d for route %s - %s", ent.dist,
+				ent.name1, ent.name2), m.file)
+		}
+		if ent.name1 == ent.name2 {
+			return nil, withFile(newParseError(ent.line, 0, "route from %s to itself", ent.name1), m.file)
+		}
+	}
+	// Destinations and scorers assume that every pair of cities has a path.
+	if comps := connectedComponents(newCityMapFromRouteEntries(m.routeEnts)); len(comps) > 1 {
+		sort.SliceStable(comps, func(i, j int) bool { return len(comps[i]) > len(comps[j]) })
+		return nil, withFile(fmt.Errorf("%s disconnected from the rest of the map (see validate-map)",
+			describeCities(comps[len(comps)-1])), m.file)
+	}
+	u = newUnivFromRouteEntries(m.routeEnts)
+	u.Name = m.Name
+	if m.doubleRouteMinPlayers > 0 {
+		u.doubleRouteMinPlayers = m.doubleRouteMinPlayers
+	}
+	u.stations = m.stations
+	if err = u.setCoords(m.coordEnts); err != nil {
+		return nil, withFile(err, m.file)
+	}
+	return
+}
+
+// Returns the routes between two adjacent cities that are usable in a game
+// with the given number of players. In a game too small for double routes,
+// only one route of a double route is usable.
+func (u *Univ) UsableRoutes(c1, c2 *City, players int) []*Route {
+	routes := c1.routes[c2]
+	if len(routes) > 1 && players < u.doubleRouteMinPlayers {
+		return routes[:1]
+	}
+	return routes
+}
+
+// Sets the positions of cities. Cities not named in the entries remain
+// unlocated.
+func (u *Univ) setCoords(ents []coordEnt) error {
+	for _, ent := range ents {
+		c := u.cityByName[ent.name]
+		if c == nil {
+			return fmt.Errorf("error setting coordinates: city %q doesn't exist", ent.name)
+		}
+		c.located = true
+		c.x = ent.x
+		c.y = ent.y
+	}
+	return nil
+}
+
+// Returns whether every city has a position.
+func (u *Univ) Located() bool {
+	for _, c := range u.cityByName {
+		if !c.located {
+			return false
+		}
+	}
+	return len(u.cityByName) > 0
+}
+
+// Returns every link in the universe, ordered alphabetically.
+func (u *Univ) allLinks() (links []link) {
+	for _, c1 := range u.Cities() {
+		for _, c2 := range c1.adj {
+			if c1.Name < c2.Name {
+				links = append(links, newLink(c1, c2))
+			}
+		}
+	}
+	return
+}
+
+// Returns every city, ordered alphabetically.
+func (u *Univ) Cities() (cities []*City) {
+	var names []string
+	for n := range u.cityByName {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		cities = append(cities, u.cityByName[n])
+	}
+	return
+}
+
+// Returns the city with the given name, or nil if there's no such city.
+func (u *Univ) City(name string) *City {
+	return u.cityByName[name]
+}
+
+// Returns the city with the given name, ignoring case, spaces, and
+// punctuation, e.g., "sault st marie" for "Sault St. Marie". If there's no such
+// city then the error suggests the closest name, if any.
+func (u *Univ) FindCity(name string) (*City, error) {
+	if c := u.cityByName[name]; c != nil {
+		return c, nil
+	}
+	var names []string
+	for _, c := range u.Cities() {
+		if normalizeName(c.Name) == normalizeName(name) {
+			return c, nil
+		}
+		names = append(names, c.Name)
+	}
+	if similar := closestName(names, name); similar != "" {
+		return nil, fmt.Errorf("city %q doesn't exist; did you mean %q?", name, similar)
+	}
+	return nil, fmt.Errorf("city %q doesn't exist", name)
+}
+
+// Returns the shortest path between two cities, breaking ties by fewest
+// hazards and then by fewest hops.
+func (u *Univ) ShortestPath(from, to string) (*Path, error) {
+	return u.bestPath(from, to, func(c *City) map[*City]*Path { return c.shortestDist })
+}
+
+// Returns the path between two cities with the fewest hops, breaking ties by
+// shortest distance and then by fewest hazards.
+func (u *Univ) FewestHopsPath(from, to string) (*Path, error) {
+	return u.bestPath(from, to, func(c *City) map[*City]*Path { return c.fewestHops })
+}
+
+func (u *Univ) bestPath(from, to string, bestPaths func(*City) map[*City]*Path) (*Path, error) {
+	c1 := u.cityByName[from]
+	if c1 == nil {
+		return nil, fmt.Errorf("city %q doesn't exist", from)
+	}
+	c2 := u.cityByName[to]
+	if c2 == nil {
+		return nil, fmt.Errorf("city %q doesn't exist", to)
+	}
+	p := bestPaths(c1)[c2]
+	if p == nil {
+		return nil, fmt.Errorf("no path from %q to %q", from, to)
+	}
+	return p, nil
+}
+
+// Returns the cities adjacent to this city, ordered alphabetically.
+func (c *City) Neighbors() []*City {
+	return c.adj
+}
+
+// Returns the routes between this city and an adjacent city.
+func (c *City) RoutesTo(adj *City) []*Route {
+	return c.routes[adj]
+}
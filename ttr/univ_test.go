@@ -0,0 +1,494 @@
+package ttr
+
+import (
+	"fmt"
+	"testing"
+)
+
+// Returns an incomplete universe containing all the cities and routes but
+// without any of the calculated paths.
+func newUnivNoPaths(ents []routeEnt) (u *Univ) {
+	u = new(Univ)
+	u.doubleRouteMinPlayers = defaultDoubleRouteMinPlayers
+	u.cityByName = newCityMapFromRouteEntries(ents)
+	return
+}
+
+func TestNewCityMapFromRouteEntries(t *testing.T) {
+	type tc struct {
+		ents         []routeEnt
+		expCityNames []string
+		expRoutes    []string
+	}
+
+	tcs := []tc{
+
+		// case: one route
+		tc{
+			[]routeEnt{
+				routeEnt{name1: "alpha", name2: "bravo", dist: 3, color: "wild"},
+			},
+			[]string{
+				"alpha",
+				"bravo",
+			},
+			[]string{
+				"alpha bravo 3 wild",
+				"bravo alpha 3 wild",
+			},
+		},
+
+		// case: double route between two cities
+		tc{
+			[]routeEnt{
+				routeEnt{name1: "alpha", name2: "bravo", dist: 3, color: "blue"},
+				routeEnt{name1: "alpha", name2: "bravo", dist: 3, color: "orange"},
+			},
+			[]string{
+				"alpha",
+				"bravo",
+			},
+			[]string{
+				"alpha bravo 3 blue",
+				"alpha bravo 3 orange",
+				"bravo alpha 3 blue",
+				"bravo alpha 3 orange",
+			},
+		},
+
+		// case: routes from one city to many cities
+		tc{
+			[]routeEnt{
+				routeEnt{name1: "alpha", name2: "bravo", dist: 3, color: "blue"},
+				routeEnt{name1: "alpha", name2: "bravo", dist: 3, color: "orange"},
+				routeEnt{name1: "alpha", name2: "charlie", dist: 2, color: "wild"},
+			},
+			[]string{
+				"alpha",
+				"bravo",
+				"charlie",
+			},
+			[]string{
+				"alpha bravo 3 blue",
+				"alpha bravo 3 orange",
+				"alpha charlie 2 wild",
+				"bravo alpha 3 blue",
+				"bravo alpha 3 orange",
+				"charlie alpha 2 wild",
+			},
+		},
+
+		// case: completely connected graph of three cities
+		tc{
+			[]routeEnt{
+				routeEnt{name1: "alpha", name2: "bravo", dist: 4, color: "wild"},
+				routeEnt{name1: "alpha", name2: "charlie", dist: 6, color: "wild"},
+				routeEnt{name1: "bravo", name2: "charlie", dist: 1, color: "wild"},
+			},
+			[]string{
+				"alpha",
+				"bravo",
+				"charlie",
+			},
+			[]string{
+				"alpha bravo 4 wild",
+				"alpha charlie 6 wild",
+				"bravo alpha 4 wild",
+				"bravo charlie 1 wild",
+				"charlie alpha 6 wild",
+				"charlie bravo 1 wild",
+			},
+		},
+	}
+
+	for _, tc := range tcs {
+		got := newCityMapFromRouteEntries(tc.ents)
+		expNames := make(map[string]bool)
+		for _, v := range tc.expCityNames {
+			expNames[v] = true
+		}
+		expRoutes := make(map[string]bool)
+		for _, v := range tc.expRoutes {
+			expRoutes[v] = true
+		}
+		// Check that all expected city names are in the map.
+		for _, expName := range tc.expCityNames {
+			if got[expName] == nil {
+				t.Errorf("missing city %q (%v)", expName, tc)
+			}
+		}
+		// Check that all actual city names are expected.
+		for gotName := range got {
+			if !expNames[gotName] {
+				t.Errorf("got unexpected city %q (%v)", gotName, tc)
+			}
+		}
+		// Check that all expected routes are in the map.
+		for _, v := range tc.expRoutes {
+			var c1Name string
+			var c2Name string
+			var dist int
+			var color string
+			if _, err := fmt.Sscan(v, &c1Name, &c2Name, &dist, &color); err != nil {
+				t.Fatalf("scan failure: %s (%q)", err, v)
+			}
+			c1 := got[c1Name]
+			c2 := got[c2Name]
+			found := false
+			for _, r := range c1.routes[c2] {
+				if r.Dist == dist && r.Color == color {
+					found = true
+					break
+				}
+			}
+			if !found {
+				t.Errorf("missing route {%q - %q, %d, %s} (%v)", c1.Name, c2.Name, dist, color, tc)
+			}
+		}
+		// Check that all actual routes are expected.
+		for origName, origCity := range got {
+			for tgtCity, routes := range origCity.routes {
+				tgtName := tgtCity.Name
+				for _, r := range routes {
+					k := fmt.Sprintf("%s %s %d %s", origName, tgtName, r.Dist, r.Color)
+					if !expRoutes[k] {
+						t.Errorf("got unexpected route {%q - %q, %d, %s} (%v)", origName, tgtName, r.Dist, r.Color, tc)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPathAppendAndChop(t *testing.T) {
+
+	c0 := newCity("alpha")
+	c1 := newCity("bravo")
+	c2 := newCity("bravo")
+	r1 := newRoute(5, "green")
+	r2 := newRoute(4, "wild")
+	p := newPath(c0)
+
+	check := func(expCities []*City, expRoutes []*Route) {
+		if len(p.Cities) != len(expCities) {
+			t.Fatalf("expected %d cities but got %d", len(expCities), len(p.Cities))
+		}
+		for i, got := range p.Cities {
+			exp := expCities[i]
+			if got != exp {
+				t.Fatalf("at index %d, expected %q but got %q", i, exp.Name, got.Name)
+			}
+		}
+		expDist := 0
+		if len(p.Routes) != len(expRoutes) {
+			t.Fatalf("expected %d routes but got %d", len(expRoutes), len(p.Routes))
+		}
+		for i, got := range p.Routes {
+			exp := expRoutes[i]
+			if got != exp {
+				t.Fatalf("at index %d, expected {%d, %s} but got {%d, %s}", i, exp.Dist, exp.Color, got.Dist, got.Color)
+			}
+			expDist += exp.Dist
+		}
+		if p.Dist != expDist {
+			t.Fatalf("expected distance %d but got %d", expDist, p.Dist)
+		}
+	}
+
+	check([]*City{c0}, []*Route{})
+	p.appendHop(c1, r1)
+	check([]*City{c0, c1}, []*Route{r1})
+	p.appendHop(c2, r2)
+	check([]*City{c0, c1, c2}, []*Route{r1, r2})
+	p.chopHop()
+	check([]*City{c0, c1}, []*Route{r1})
+	p.chopHop()
+	check([]*City{c0}, []*Route{})
+}
+
+func TestPathEquals(t *testing.T) {
+	c1 := newCity("alpha")
+	c2 := newCity("bravo")
+	c3 := newCity("charlie")
+	var p1, p2 *Path
+
+	p1 = newPath(c1)
+
+	// check: same path object is same
+	if !p1.Equals(p1) {
+		t.Errorf("path object reported unequal to self")
+	}
+
+	// check: one-hop paths that are the same
+	p1 = newPath(c1)
+	p2 = newPath(c1)
+	if !p1.Equals(p2) {
+		t.Errorf("similar one-hop paths reported unequal")
+	}
+
+	// check: one-hop paths that are different
+	p1 = newPath(c1)
+	p2 = newPath(c2)
+	if p1.Equals(p2) {
+		t.Errorf("different one-hop paths reported equal")
+	}
+
+	// check: multiple-paths that are the same
+	p1 = newPath(c1)
+	p1.appendHop(c2, newRoute(2, "red"))
+	p2 = newPath(c1)
+	p2.appendHop(c2, newRoute(2, "red"))
+	if !p1.Equals(p2) {
+		t.Errorf("similar multi-hop paths reported unequal")
+	}
+
+	// check: multiple-paths that have different hops
+	p1 = newPath(c1)
+	p1.appendHop(c2, newRoute(2, "red"))
+	p2 = newPath(c1)
+	p2.appendHop(c3, newRoute(2, "red"))
+	if p1.Equals(p2) {
+		t.Errorf("similar multi-hop paths reported equal")
+	}
+
+	// check: multiple-paths that have different routes
+	p1 = newPath(c1)
+	p1.appendHop(c2, newRoute(2, "red"))
+	p2 = newPath(c1)
+	p2.appendHop(c2, newRoute(2, "blue"))
+	if p1.Equals(p2) {
+		t.Errorf("similar multi-hop paths reported equal")
+	}
+}
+
+func TestCityFindBestPaths(t *testing.T) {
+
+	// alpha-charlie is one long hop, alpha-bravo-charlie is two short hops, and
+	// delta is reachable only via charlie.
+	u := newUnivNoPaths(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 1 wild
+		bravo - charlie: 1 red, 2 blue
+		alpha - charlie: 5 wild
+		charlie - delta: 3 green
+	`))
+	alpha := u.cityByName["alpha"]
+
+	type tc struct {
+		tgtName string
+		expHops int
+		expDist int
+	}
+
+	check := func(comp pathComparer, tcs []tc) {
+		bestPaths := make(map[*City]*Path)
+		alpha.findBestPaths(comp, bestPaths)
+		if len(bestPaths) != len(u.cityByName) {
+			t.Errorf("expected %d paths but got %d", len(u.cityByName), len(bestPaths))
+		}
+		for _, tc := range tcs {
+			p := bestPaths[u.cityByName[tc.tgtName]]
+			if p == nil {
+				t.Errorf("missing path to %q", tc.tgtName)
+				continue
+			}
+			if p.Cities[0] != alpha || p.Cities[len(p.Cities)-1] != u.cityByName[tc.tgtName] {
+				t.Errorf("path %v has wrong endpoints", p)
+			}
+			if len(p.Routes) != tc.expHops || p.Dist != tc.expDist {
+				t.Errorf("to %q, expected %d hops, %d length but got %d hops, %d length (%v)", tc.tgtName, tc.expHops,
+					tc.expDist, len(p.Routes), p.Dist, p)
+			}
+		}
+	}
+
+	check(compFewestHops, []tc{
+		{"alpha", 0, 0},
+		{"bravo", 1, 1},
+		{"charlie", 1, 5},
+		{"delta", 2, 8},
+	})
+
+	check(compShortestDist, []tc{
+		{"alpha", 0, 0},
+		{"bravo", 1, 1},
+		{"charlie", 2, 2},
+		{"delta", 3, 5},
+	})
+}
+
+func TestCompShortestDistHazards(t *testing.T) {
+	c1 := newCity("alpha")
+	c2 := newCity("bravo")
+	c3 := newCity("charlie")
+
+	// one hop through a tunnel versus two hops of the same length without
+	p1 := newPath(c1)
+	tunnel := newRoute(4, "wild")
+	tunnel.Tunnel = true
+	p1.appendHop(c3, tunnel)
+	p2 := newPath(c1)
+	p2.appendHop(c2, newRoute(2, "wild"))
+	p2.appendHop(c3, newRoute(2, "wild"))
+
+	if compShortestDist(p2, p1) <= 0 {
+		t.Errorf("expected hazard-free path to be better")
+	}
+	if compFewestHops(p1, p2) <= 0 {
+		t.Errorf("expected one-hop path to be better")
+	}
+	p1.chopHop()
+	if p1.Hazards != 0 {
+		t.Errorf("expected no hazards after chop but got %d", p1.Hazards)
+	}
+}
+
+func TestRouteString(t *testing.T) {
+	check := func(r *Route, exp string) {
+		if got := r.String(); got != exp {
+			t.Errorf("expected %q but got %q", exp, got)
+		}
+	}
+	r := newRoute(3, "red")
+	check(r, "3 red")
+	r.Tunnel = true
+	check(r, "3 red tunnel")
+	r.Ferries = 2
+	check(r, "3 red tunnel ferry=2")
+	if r.Hazards() != 3 {
+		t.Errorf("expected 3 hazards but got %d", r.Hazards())
+	}
+}
+
+func TestUnivUsableRoutes(t *testing.T) {
+	u := newUnivNoPaths(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 2 red, 2 blue
+		bravo - charlie: 1 wild
+	`))
+	alpha := u.cityByName["alpha"]
+	bravo := u.cityByName["bravo"]
+	charlie := u.cityByName["charlie"]
+	check := func(c1, c2 *City, players, exp int) {
+		if got := len(u.UsableRoutes(c1, c2, players)); got != exp {
+			t.Errorf("with %q – %q and %d players, expected %d routes but got %d", c1.Name, c2.Name, players, exp, got)
+		}
+	}
+	check(alpha, bravo, 4, 2)
+	check(alpha, bravo, 5, 2)
+	check(alpha, bravo, 3, 1)
+	check(bravo, charlie, 2, 1)
+	check(alpha, charlie, 4, 0)
+}
+
+func TestUnivSetCoords(t *testing.T) {
+	u := newUnivNoPaths(mustLoadRouteEntriesFromString("alpha - bravo: 1 wild\n"))
+	if u.Located() {
+		t.Errorf("expected unlocated universe but got located")
+	}
+	if err := u.setCoords([]coordEnt{{"alpha", 1, 2}}); err != nil {
+		t.Fatalf("got error setting coordinates: %s", err)
+	}
+	if u.Located() {
+		t.Errorf("expected unlocated universe but got located")
+	}
+	if err := u.setCoords([]coordEnt{{"bravo", 3, 4}}); err != nil {
+		t.Fatalf("got error setting coordinates: %s", err)
+	}
+	if !u.Located() {
+		t.Errorf("expected located universe but got unlocated")
+	}
+	if c := u.cityByName["bravo"]; c.x != 3 || c.y != 4 {
+		t.Errorf("expected (3, 4) but got (%v, %v)", c.x, c.y)
+	}
+	if err := u.setCoords([]coordEnt{{"charlie", 3, 4}}); err == nil {
+		t.Errorf("expected error for unknown city but got none")
+	}
+}
+
+func TestUnivAllLinks(t *testing.T) {
+	u := newUnivNoPaths(mustLoadRouteEntriesFromString(`
+		charlie - alpha: 1 wild
+		alpha - bravo: 1 red, 1 blue
+		bravo - charlie: 2 wild
+	`))
+	var got []string
+	for _, l := range u.allLinks() {
+		got = append(got, l.city1.Name+"-"+l.city2.Name)
+	}
+	exp := []string{"alpha-bravo", "alpha-charlie", "bravo-charlie"}
+	if fmt.Sprint(got) != fmt.Sprint(exp) {
+		t.Errorf("expected %v but got %v", exp, got)
+	}
+}
+
+func TestUnivFindCity(t *testing.T) {
+	u := newUnivNoPaths(mustLoadRouteEntriesFromString("Sault St. Marie - Montreal: 5 dark\n"))
+	check := func(name, exp, expErr string) {
+		c, err := u.FindCity(name)
+		switch {
+		case expErr != "":
+			if err == nil || err.Error() != expErr {
+				t.Errorf("with %q, expected error %q but got %v", name, expErr, err)
+			}
+		case err != nil:
+			t.Errorf("with %q, got error %q", name, err)
+		case c.Name != exp:
+			t.Errorf("with %q, expected %q but got %q", name, exp, c.Name)
+		}
+	}
+	check("Montreal", "Montreal", "")
+	check("montreal", "Montreal", "")
+	check("sault st marie", "Sault St. Marie", "")
+	check("Montral", "", `city "Montral" doesn't exist; did you mean "Montreal"?`)
+	check("Paris", "", `city "Paris" doesn't exist`)
+}
+
+func TestPathCardsNeeded(t *testing.T) {
+	u := newUnivFromRouteEntries(mustLoadRouteEntriesFromString(`
+		alpha - bravo: 3 red
+		bravo - charlie: 2 wild
+		charlie - delta: 4 red tunnel
+		delta - echo: 3 wild ferry=1
+	`))
+	p := u.cityByName["alpha"].shortestDist[u.cityByName["echo"]]
+	exp := []ColorCount{{"wild", 4}, {"red", 7}, {"locomotive", 1}}
+	if got := p.CardsNeeded(); fmt.Sprint(got) != fmt.Sprint(exp) {
+		t.Errorf("expected %v but got %v", exp, got)
+	}
+}
+
+func BenchmarkNewDefaultUniverse(b *testing.B) {
+	routeEnts := mustLoadRouteEntriesFromFile("../routes.dat")
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		newUnivFromRouteEntries(routeEnts)
+	}
+}
+
+func TestNewUnivSelfLoop(t *testing.T) {
+	m := &Map{routeEnts: mustLoadRouteEntriesFromString(`
+		alpha - bravo: 1 wild
+		bravo - bravo: 2 red
+	`)}
+	_, err := NewUniv(m)
+	if pe, ok := err.(*ParseError); !ok || pe.Line != 3 {
+		t.Errorf("expected parse error at line 3 but got %v", err)
+	}
+}
+
+func TestNewUnivDisconnected(t *testing.T) {
+	m := &Map{routeEnts: mustLoadRouteEntriesFromString(`
+		alpha - bravo: 1 wild
+		bravo - charlie: 1 wild
+		delta - echo: 1 wild
+	`)}
+	_, err := NewUniv(m)
+	if exp := `cities "delta", "echo" are disconnected from the rest of the map (see validate-map)`; err == nil ||
+		err.Error() != exp {
+		t.Errorf("expected error %q but got %v", exp, err)
+	}
+
+	m.routeEnts = append(m.routeEnts, mustLoadRouteEntriesFromString("charlie - delta: 1 wild\n")...)
+	if _, err := NewUniv(m); err != nil {
+		t.Errorf("got error for connected map: %s", err)
+	}
+}
@@ -0,0 +1,260 @@
+package ttr
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// A map problem is a likely mistake in a map or destination file. Problems
+// don't prevent loading a map, but they make for a broken game: for example, a
+// misspelled city name in a route creates a new city cut off from the rest of
+// the map.
+type MapProblem struct {
+	File string
+	Line int // zero if unknown
+	Msg  string
+}
+
+func (p MapProblem) String() string {
+	if p.Line == 0 {
+		return fmt.Sprintf("%s: %s", p.File, p.Msg)
+	}
+	return fmt.Sprintf("%s:%d: %s", p.File, p.Line, p.Msg)
+}
+
+// Checks a map and its destinations for problems. The file names are used only
+// for reporting. Problems are ordered by file and then by line.
+func validateMap(m *Map, mapFile string, destEnts []destEnt, destFile string) (problems []MapProblem) {
+	report := func(file string, line int, format string, a ...interface{}) {
+		problems = append(problems, MapProblem{File: file, Line: line, Msg: fmt.Sprintf(format, a...)})
+	}
+
+	// the line on which each city first appears:
+	firstLine := make(map[string]int)
+	var names []string
+	for _, ent := range m.routeEnts {
+		for _, name := range []string{ent.name1, ent.name2} {
+			if _, ok := firstLine[name]; !ok {
+				firstLine[name] = ent.line
+				names = append(names, name)
+			}
+		}
+	}
+
+	// individual routes:
+	routesByLink := make(map[[2]string][]routeEnt)
+	var linkKeys [][2]string
+	for _, ent := range m.routeEnts {
+		if ent.dist <= 0 {
+			report(mapFile, ent.line, "non-positive distance %d for route %s - %s", ent.dist, ent.name1, ent.name2)
+		}
+		if ent.ferries > ent.dist {
+			report(mapFile, ent.line, "ferry count %d exceeds distance %d for route %s - %s", ent.ferries, ent.dist,
+				ent.name1, ent.name2)
+		}
+		if !isRouteColor(ent.color) {
+			report(mapFile, ent.line, "unknown color %q for route %s - %s (colors are %s)", ent.color, ent.name1,
+				ent.name2, strings.Join(routeColors, ", "))
+		}
+		if ent.name1 == ent.name2 {
+			report(mapFile, ent.line, "route from %s to itself", ent.name1)
+			continue
+		}
+		key := [2]string{ent.name1, ent.name2}
+		if key[1] < key[0] {
+			key[0], key[1] = key[1], key[0]
+		}
+		if routesByLink[key] == nil {
+			linkKeys = append(linkKeys, key)
+		}
+		routesByLink[key] = append(routesByLink[key], ent)
+	}
+
+	// duplicate routes, which are parallel routes that couldn't be a double
+	// route:
+	for _, key := range linkKeys {
+		ents := routesByLink[key]
+		if len(ents) > 2 {
+			report(mapFile, ents[2].line, "%d routes between %s and %s, but a double route has only two", len(ents),
+				key[0], key[1])
+			continue
+		}
+		if len(ents) == 2 {
+			a, b := ents[0], ents[1]
+			switch {
+			case a.color == b.color && a.color != "wild" && a.line == b.line:
+				report(mapFile, b.line, "duplicate %s route between %s and %s", b.color, key[0], key[1])
+			case a.color == b.color && a.color != "wild":
+				report(mapFile, b.line, "duplicate %s route between %s and %s (also at line %d)", b.color, key[0],
+					key[1], a.line)
+			case a.dist != b.dist:
+				report(mapFile, b.line, "double route between %s and %s has different distances (%d at line %d, %d)",
+					key[0], key[1], a.dist, a.line, b.dist)
+			}
+		}
+	}
+
+	// near-duplicate city names:
+	for i, name1 := range names {
+		for _, name2 := range names[:i] {
+			if namesAreSimilar(name1, name2) {
+				report(mapFile, firstLine[name1], "city %q looks like %q (line %d)", name1, name2, firstLine[name2])
+			}
+		}
+	}
+
+	// disconnected components, reported for all but the largest:
+	comps := connectedComponents(newCityMapFromRouteEntries(m.routeEnts))
+	if len(comps) > 1 {
+		sort.SliceStable(comps, func(i, j int) bool { return len(comps[i]) > len(comps[j]) })
+		for _, comp := range comps[1:] {
+			line := 0
+			for _, name := range comp {
+				if line == 0 || firstLine[name] < line {
+					line = firstLine[name]
+				}
+			}
+			report(mapFile, line, "%s disconnected from the rest of the map", describeCities(comp))
+		}
+	}
+
+	// destinations:
+	for _, ent := range destEnts {
+		for _, name := range []string{ent.name1, ent.name2} {
+			if _, ok := firstLine[name]; ok {
+				continue
+			}
+			if similar := closestName(names, name); similar != "" {
+				report(destFile, ent.line, "unknown city %q in destination %s - %s (did you mean %q?)", name, ent.name1,
+					ent.name2, similar)
+			} else {
+				report(destFile, ent.line, "unknown city %q in destination %s - %s", name, ent.name1, ent.name2)
+			}
+		}
+	}
+
+	sort.SliceStable(problems, func(i, j int) bool {
+		if problems[i].File != problems[j].File {
+			return problems[i].File == mapFile // map file first
+		}
+		return problems[i].Line < problems[j].Line
+	})
+	return
+}
+
+// Returns the names of the cities in each connected component of a map, with
+// each component's names sorted alphabetically and the components ordered by
+// their first name.
+func connectedComponents(cityByName map[string]*City) (comps [][]string) {
+	var names []string
+	for name := range cityByName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	seen := make(map[*City]bool)
+	for _, name := range names {
+		start := cityByName[name]
+		if seen[start] {
+			continue
+		}
+		var comp []string
+		pending := []*City{start}
+		seen[start] = true
+		for len(pending) > 0 {
+			c := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			comp = append(comp, c.Name)
+			for _, adj := range c.adj {
+				if !seen[adj] {
+					seen[adj] = true
+					pending = append(pending, adj)
+				}
+			}
+		}
+		sort.Strings(comp)
+		comps = append(comps, comp)
+	}
+	return
+}
+
+func describeCities(names []string) string {
+	if len(names) == 1 {
+		return fmt.Sprintf("city %q is", names[0])
+	}
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return fmt.Sprintf("cities %s are", strings.Join(quoted, ", "))
+}
+
+// Returns whether two different city names are so alike that one is probably a
+// misspelling of the other, e.g., "Sault St Marie" and "Sault St. Marie".
+func namesAreSimilar(name1, name2 string) bool {
+	n1, n2 := normalizeName(name1), normalizeName(name2)
+	if n1 == n2 {
+		return true
+	}
+	// Short names are too easily within one edit of each other.
+	const minLen = 5
+	return len(n1) >= minLen && len(n2) >= minLen && editDistance(n1, n2) <= 1
+}
+
+// Returns the name among names that the given name is most likely a
+// misspelling of, or the empty string if there's no such name.
+func closestName(names []string, name string) (closest string) {
+	norm := normalizeName(name)
+	best := -1
+	for _, n := range names {
+		d := editDistance(norm, normalizeName(n))
+		if d <= len(norm)/3 && (best == -1 || d < best) {
+			closest, best = n, d
+		}
+	}
+	return
+}
+
+// Lowercases a name and strips everything other than letters and digits.
+func normalizeName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// Returns the Levenshtein distance between two strings: the fewest single-rune
+// insertions, deletions, and substitutions that change one into the other.
+func editDistance(s1, s2 string) int {
+	r1, r2 := []rune(s1), []rune(s2)
+	prev := make([]int, len(r2)+1)
+	cur := make([]int, len(r2)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(r1); i++ {
+		cur[0] = i
+		for j := 1; j <= len(r2); j++ {
+			cost := 1
+			if r1[i-1] == r2[j-1] {
+				cost = 0
+			}
+			cur[j] = minInt(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(r2)]
+}
+
+func minInt(x int, xs ...int) int {
+	for _, y := range xs {
+		if y < x {
+			x = y
+		}
+	}
+	return x
+}
@@ -0,0 +1,122 @@
+package ttr
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateMap(t *testing.T) {
+	routeEnts, err := loadRouteEntries(strings.NewReader(`alpha - bravo: 2 wild, 2 wild
+bravo - charlie: 0 red
+charlie - delta: 2 purple
+delta - alpha: 3 blue, 3 blue
+Sault St Marie - alpha: 1 red
+Sault St. Marie - bravo: 1 red
+echo - foxtrot: 1 red
+golf - golf: 1 red
+alpha - bravo: 2 wild
+charlie - delta: 2 red, 3 red
+`))
+	if err != nil {
+		t.Fatalf("got error loading routes: %s", err)
+	}
+	// The route-file loader rejects too many ferries, but other sources of maps
+	// might not.
+	routeEnts = append(routeEnts, routeEnt{name1: "alpha", name2: "charlie", dist: 1, color: "wild", ferries: 3, line: 11})
+	destEnts, err := loadDestEntries(strings.NewReader("alpha - charlie: 4\nalpha - Delta: 5\n"))
+	if err != nil {
+		t.Fatalf("got error loading destinations: %s", err)
+	}
+	problems := validateMap(&Map{routeEnts: routeEnts}, "routes", destEnts, "dests")
+
+	type tc struct {
+		file   string
+		line   int
+		substr string
+	}
+	tcs := []tc{
+		{"routes", 2, "non-positive distance"},
+		{"routes", 3, "unknown color \"purple\""},
+		{"routes", 4, "duplicate blue route"},
+		{"routes", 6, "\"Sault St. Marie\" looks like \"Sault St Marie\""},
+		{"routes", 7, "cities \"echo\", \"foxtrot\" are disconnected"},
+		{"routes", 8, "route from golf to itself"},
+		{"routes", 8, "city \"golf\" is disconnected"},
+		{"routes", 9, "3 routes between alpha and bravo"},
+		{"routes", 10, "3 routes between charlie and delta"},
+		{"routes", 11, "ferry count 3 exceeds distance 1"},
+		{"dests", 2, "unknown city \"Delta\" in destination alpha - Delta (did you mean \"delta\"?)"},
+	}
+	if len(problems) != len(tcs) {
+		t.Fatalf("expected %d problem(s) but got %d: %v", len(tcs), len(problems), problems)
+	}
+	for i, tc := range tcs {
+		p := problems[i]
+		if p.File != tc.file || p.Line != tc.line || !strings.Contains(p.Msg, tc.substr) {
+			t.Errorf("expected problem %d to be at %s:%d and contain %q but got %q", i, tc.file, tc.line, tc.substr, p)
+		}
+	}
+}
+
+func TestValidateMapReal(t *testing.T) {
+	for _, name := range BundledMapNames() {
+		m := mustLoadMap(name)
+		if problems := validateMap(m, name, m.destEnts, name); len(problems) != 0 {
+			t.Errorf("%s: expected no problems but got %v", name, problems)
+		}
+	}
+}
+
+func TestConnectedComponents(t *testing.T) {
+	u := newUnivNoPaths([]routeEnt{
+		routeEnt{name1: "alpha", name2: "bravo", dist: 1, color: "red"},
+		routeEnt{name1: "charlie", name2: "delta", dist: 1, color: "red"},
+		routeEnt{name1: "bravo", name2: "echo", dist: 1, color: "red"},
+	})
+	comps := connectedComponents(u.cityByName)
+	exp := [][]string{{"alpha", "bravo", "echo"}, {"charlie", "delta"}}
+	if len(comps) != len(exp) {
+		t.Fatalf("expected %v but got %v", exp, comps)
+	}
+	for i := range exp {
+		if strings.Join(comps[i], ",") != strings.Join(exp[i], ",") {
+			t.Errorf("expected %v but got %v", exp, comps)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	type tc struct {
+		s1, s2 string
+		exp    int
+	}
+	for _, tc := range []tc{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"saultstmarie", "saultstemarie", 1},
+		{"Montréal", "Montreal", 1},
+	} {
+		if got := editDistance(tc.s1, tc.s2); got != tc.exp {
+			t.Errorf("expected distance between %q and %q to be %d but got %d", tc.s1, tc.s2, tc.exp, got)
+		}
+	}
+}
+
+func TestNamesAreSimilar(t *testing.T) {
+	type tc struct {
+		name1, name2 string
+		exp          bool
+	}
+	for _, tc := range []tc{
+		{"Sault St Marie", "Sault St. Marie", true},
+		{"El Paso", "el paso", true},
+		{"Pittsburg", "Pittsburgh", true},
+		{"Denver", "Dallas", false},
+		{"Oslo", "Olso", false}, // too short to tell
+	} {
+		if got := namesAreSimilar(tc.name1, tc.name2); got != tc.exp {
+			t.Errorf("expected similarity of %q and %q to be %v but got %v", tc.name1, tc.name2, tc.exp, got)
+		}
+	}
+}